@@ -8,11 +8,79 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/RZhurakovskiy/agent/server/db"
+	"github.com/RZhurakovskiy/agent/server/getmetrics"
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/RZhurakovskiy/agent/server/services"
 	"github.com/shirou/gopsutil/v4/process"
 )
 
 var CPUThresholdPercent float64
 
+// securityScanner хранит CPU-время процессов между проверками, чтобы повторная проверка не ждала замера CPU.
+var securityScanner = getmetrics.NewSecurityScanner()
+
+// integrityChecker проверяет исполняемые файлы по спискам из базы сервера (db.DefaultPath); nil, пока база не открыта.
+var integrityChecker *services.IntegrityChecker
+
+// initIntegrityChecker открывает базу со списками проверки целостности и подключает их к проверке процессов.
+// База не создаётся: если сервер ещё не запускался, проверка процессов работает без вердиктов целостности.
+func initIntegrityChecker() {
+	if integrityChecker != nil {
+		return
+	}
+
+	sqlDB, err := db.OpenExisting(db.DefaultPath)
+	if err != nil {
+		log.Println("Списки проверки целостности недоступны:", err)
+		return
+	}
+	checker, err := services.NewIntegrityChecker(sqlDB)
+	if err != nil {
+		sqlDB.Close()
+		log.Println("Списки проверки целостности недоступны:", err)
+		return
+	}
+
+	checker.Start()
+	integrityChecker = checker
+	securityScanner.SetVerifier(checker)
+}
+
+// addIntegrityRule вносит хэш исполняемого файла процесса в список разрешённых или запрещённых.
+func addIntegrityRule(list string, pid int32) {
+	if integrityChecker == nil {
+		fmt.Println("Списки проверки целостности недоступны: не удалось открыть базу", db.DefaultPath)
+		return
+	}
+	if pid <= 0 {
+		return
+	}
+
+	hash, exe, err := integrityChecker.HashProcess(pid)
+	if err != nil {
+		fmt.Println("Не удалось посчитать хэш исполняемого файла:", err)
+		return
+	}
+
+	rule, err := integrityChecker.AddRule(models.IntegrityRule{
+		List:    list,
+		Kind:    models.IntegrityKindHash,
+		Value:   hash,
+		Comment: fmt.Sprintf("%s (PID %d, из CLI)", exe, pid),
+	})
+	if err != nil {
+		fmt.Println("Не удалось добавить правило:", err)
+		return
+	}
+
+	if list == models.IntegrityListDeny {
+		fmt.Printf("Файл %s (SHA-256 %s) добавлен в список запрещённых, правило #%d\n", exe, hash, rule.ID)
+	} else {
+		fmt.Printf("Файл %s (SHA-256 %s) добавлен в список разрешённых, правило #%d\n", exe, hash, rule.ID)
+	}
+}
+
 func viewProcess() {
 	procs, err := process.Processes()
 	fmt.Println("\n-------------------------------------------------------")
@@ -163,8 +231,51 @@ func startDaemonMode(threshold float64) {
 	}
 }
 
-func checkingSuspiciousActivity() {
-	fmt.Println("Проверка подозрительных процессов: в разработке")
+// checkingSuspiciousActivity проверяет процессы эвристиками подозрительной активности и выводит
+// находки с серьёзностью не ниже minSeverity вместе с объяснениями.
+func checkingSuspiciousActivity(minSeverity string) {
+	fmt.Println("\n-------------------------------------------------------")
+	fmt.Println("Проверка подозрительных процессов... (займёт ~1 секунду, если предыдущая проверка была давно)")
+	fmt.Println("-------------------------------------------------------")
+
+	report, err := securityScanner.Scan()
+	if err != nil {
+		log.Println("Не удалось проверить процессы:", err)
+		return
+	}
+
+	findings := getmetrics.FilterFindings(report.Findings, minSeverity)
+	if len(findings) == 0 {
+		fmt.Printf("Проверено процессов: %d. Подозрительных процессов не найдено.\n", report.Scanned)
+		printIntegritySummary(report)
+		fmt.Println("---------------------------------")
+		return
+	}
+
+	fmt.Printf("\n%-10s %-10s %-20s %s\n", "Уровень", "PID", "Название", "Находка")
+	fmt.Printf("%-10s %-10s %-20s %s\n", "----------", "----------", "--------------------", "--------------------")
+	for _, finding := range findings {
+		fmt.Printf("%-10s %-10d %-20s %s\n", finding.Severity, finding.PID, finding.Name, finding.Title)
+		fmt.Printf("%-10s %-10s %-20s %s\n", "", "", "", finding.Explanation)
+	}
+	fmt.Println("---------------------------------")
+	fmt.Printf("Проверено процессов: %d. Показано находок: %d из %d (critical: %d, high: %d, medium: %d, low: %d)\n",
+		report.Scanned, len(findings), len(report.Findings), report.BySeverity["critical"], report.BySeverity["high"],
+		report.BySeverity["medium"], report.BySeverity["low"])
+	printIntegritySummary(report)
+	fmt.Println("---------------------------------")
+}
+
+// printIntegritySummary выводит число процессов по вердиктам проверки целостности, если списки подключены.
+func printIntegritySummary(report models.SecurityReport) {
+	if len(report.Integrity) == 0 && report.PendingHashes == 0 {
+		return
+	}
+	fmt.Printf("Исполняемые файлы: известных %d, неизвестных %d, запрещённых %d\n",
+		report.Integrity[models.IntegrityKnown], report.Integrity[models.IntegrityUnknown], report.Integrity[models.IntegrityDenied])
+	if report.PendingHashes > 0 {
+		fmt.Printf("Хэши ещё считаются для %d процессов: их вердикты по хэшу появятся при следующей проверке\n", report.PendingHashes)
+	}
 }
 
 func checkAndLogHeavyProcesses(logFile *os.File) {
@@ -3,6 +3,7 @@ package cpu
 import (
 	"fmt"
 
+	"github.com/RZhurakovskiy/agent/server/models"
 	"github.com/RZhurakovskiy/agent/ui"
 	"github.com/RZhurakovskiy/agent/utils"
 )
@@ -33,12 +34,27 @@ func ProcessMenu() {
 			}
 
 		case 3:
-			checkingSuspiciousActivity()
-			action := ui.СheckSuspiciousActivityMenu()
+			initIntegrityChecker()
+			minSeverity := models.SeverityLow
+			for action := 1; action != 0; {
+				checkingSuspiciousActivity(minSeverity)
+				var pid int32
+				action, pid = ui.СheckSuspiciousActivityMenu()
 
-			switch action {
-			case 0:
+				switch action {
+				case 1:
+					minSeverity = models.SeverityLow
+				case 2:
+					minSeverity = models.SeverityHigh
+				case 3:
+					addIntegrityRule(models.IntegrityListAllow, pid)
+				case 4:
+					addIntegrityRule(models.IntegrityListDeny, pid)
+				case 0:
 
+				default:
+					fmt.Println("Неверный выбор в подменю.")
+				}
 			}
 		case 4:
 			var thresHold float64
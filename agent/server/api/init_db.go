@@ -12,7 +12,7 @@ func InitDB(dbPath string) (*sql.DB, error) {
 	if err != nil {
 		return nil, err
 	}
-	if _, err := sqlDB.Exec(db.SchemaSQL); err != nil {
+	if err := db.Migrate(sqlDB); err != nil {
 		sqlDB.Close()
 		return nil, err
 	}
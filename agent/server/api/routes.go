@@ -1,23 +1,36 @@
 package api
 
 import (
+	"database/sql"
 	"net/http"
 
 	"github.com/RZhurakovskiy/agent/server/handlers"
+	"github.com/RZhurakovskiy/agent/server/services"
 	"github.com/RZhurakovskiy/agent/server/ws"
 )
 
-func SetupRoutes(mux *http.ServeMux) {
+// Dependencies содержит общие для обработчиков ресурсы, создаваемые при старте сервера.
+type Dependencies struct {
+	DB        *sql.DB
+	Spikes    *services.SpikeRecorder
+	Sessions  *services.SessionRecorder
+	History   *services.HistoryRecorder
+	Lifecycle *services.LifecycleTracker
+	Audit     *services.AuditLog
+	Integrity *services.IntegrityChecker
+}
+
+func SetupRoutes(mux *http.ServeMux, deps *Dependencies) {
 
 	mux.HandleFunc("/api/gethostusername", handlers.GetHostUserName)
-	mux.HandleFunc("/api/kill-process-by-id", handlers.KillProcessById)
+	mux.HandleFunc("/api/kill-process-by-id", handlers.KillProcessById(deps.Audit))
 	mux.HandleFunc("/api/get-host-username", handlers.GetHostUserName)
 
 	mux.HandleFunc("/api/get-device-info", handlers.GetDeviceInfo)
 
 	mux.HandleFunc("/api/listening-ports", handlers.GetListeningPort)
 
-	mux.HandleFunc("/api/start-processes", handlers.StartProcess)
+	mux.HandleFunc("/api/start-processes", handlers.StartProcess(deps.Audit))
 
 	mux.HandleFunc("/api/monitoring-status", func(writer http.ResponseWriter, request *http.Request) {
 		switch request.Method {
@@ -30,7 +43,40 @@ func SetupRoutes(mux *http.ServeMux) {
 		}
 	})
 
+	mux.HandleFunc("/api/spikes", handlers.GetSpikes(deps.DB))
+	mux.HandleFunc("/api/spikes/config", handlers.SpikeConfig(deps.Spikes))
+
+	mux.HandleFunc("/api/sessions", handlers.Sessions(deps.DB, deps.Sessions))
+	mux.HandleFunc("/api/sessions/{id}/processes/{pid}", handlers.GetSessionProcessHistory(deps.DB))
+
+	mux.HandleFunc("/api/history/config", handlers.HistoryConfig(deps.History))
+	mux.HandleFunc("/api/history/{metric}", handlers.GetHistory(deps.History))
+
+	mux.HandleFunc("/api/process-events", handlers.GetProcessEvents(deps.DB))
+	mux.HandleFunc("/api/processes", handlers.GetProcesses)
+	mux.HandleFunc("/api/processes/{pid}", handlers.GetProcessDetails)
+	mux.HandleFunc("/api/process-tree", handlers.GetProcessTree)
+	mux.HandleFunc("/api/cgroups", handlers.GetCgroups)
+
+	mux.HandleFunc("/api/security/findings", handlers.GetSecurityFindings(deps.Integrity))
+	mux.HandleFunc("/api/integrity/rules", handlers.IntegrityRules(deps.Integrity, deps.Audit))
+	mux.HandleFunc("/api/integrity/rules/{id}", handlers.DeleteIntegrityRule(deps.Integrity, deps.Audit))
+	mux.HandleFunc("/api/integrity/events", handlers.GetIntegrityEvents(deps.DB))
+
+	mux.HandleFunc("/api/audit", handlers.GetAudit(deps.DB))
+
+	mux.HandleFunc("/api/disk", handlers.GetDisk)
+	mux.HandleFunc("/api/network", handlers.GetNetwork)
+	mux.HandleFunc("/api/network/stack", handlers.GetTCPStack)
+	mux.HandleFunc("/api/connections", handlers.GetConnections)
+	mux.HandleFunc("/api/connections/summary", handlers.GetConnectionsSummary)
+
+	mux.HandleFunc("/ws", ws.StreamMux)
 	mux.HandleFunc("/ws/cpu", ws.StreamCPU)
 	mux.HandleFunc("/ws/memory", ws.StreamMemory)
 	mux.HandleFunc("/ws/processes", ws.StreamProcesses)
+	mux.HandleFunc("/ws/process-events", ws.StreamProcessEvents)
+	mux.HandleFunc("/ws/disk", ws.StreamDisk)
+	mux.HandleFunc("/ws/network", ws.StreamNetwork)
+	mux.HandleFunc("/ws/connections", ws.StreamConnections)
 }
@@ -9,20 +9,49 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/RZhurakovskiy/agent/server/db"
 	"github.com/RZhurakovskiy/agent/server/middleware"
+	"github.com/RZhurakovskiy/agent/server/services"
+	"github.com/RZhurakovskiy/agent/server/ws"
 )
 
 func StartServer(port string) {
 
-	sqlDB, err := InitDB("./monitor.db")
+	sqlDB, err := InitDB(db.DefaultPath)
 	if err != nil {
 		log.Fatalf("Ошибка инициализации БД: %v", err)
 	}
 	defer sqlDB.Close()
 
+	sessions, err := services.NewSessionRecorder(sqlDB)
+	if err != nil {
+		log.Fatalf("Ошибка инициализации сессий записи: %v", err)
+	}
+
+	integrity, err := services.NewIntegrityChecker(sqlDB)
+	if err != nil {
+		log.Fatalf("Ошибка инициализации проверки целостности: %v", err)
+	}
+
+	deps := &Dependencies{
+		DB:        sqlDB,
+		Spikes:    services.NewSpikeRecorder(sqlDB, services.DefaultSpikeConfig),
+		Sessions:  sessions,
+		History:   services.NewHistoryRecorder(sqlDB, services.DefaultHistoryConfig),
+		Lifecycle: services.NewLifecycleTracker(sqlDB),
+		Audit:     services.NewAuditLog(sqlDB),
+		Integrity: integrity,
+	}
+	ws.SetSpikeRecorder(deps.Spikes)
+	ws.SetHistoryRecorder(deps.History)
+	ws.SetLifecycleTracker(deps.Lifecycle)
+	ws.SetIntegrityChecker(deps.Integrity)
+	deps.History.Start()
+	deps.Integrity.Start()
+
 	mux := http.NewServeMux()
 
-	SetupRoutes(mux)
+	SetupRoutes(mux, deps)
 
 	handler := middleware.CorsMiddleware(mux)
 
@@ -67,5 +96,10 @@ func StartServer(port string) {
 		log.Fatalf("Ошибка при остановке сервера: %v", err)
 	}
 
+	deps.Spikes.Flush()
+	deps.Sessions.StopAll()
+	deps.History.Stop()
+	deps.Integrity.Stop()
+
 	log.Println("Сервер успешно остановлен")
 }
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/models"
+)
+
+// AuditFilter задаёт условия выборки журнала аудита. Нулевые поля не участвуют в фильтрации.
+type AuditFilter struct {
+	Action  string
+	PID     int32
+	Outcome string
+	Client  string
+	From    time.Time
+	To      time.Time
+	Limit   int
+	Offset  int
+}
+
+// InsertAuditEntry сохраняет запись журнала аудита.
+func InsertAuditEntry(sqlDB *sql.DB, entry models.AuditEntry, createdAt time.Time) error {
+	_, err := sqlDB.Exec(
+		`INSERT INTO audit_log (created_at, action, pid, target_name, target_cmdline, params, client_addr, outcome, error)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		FormatTime(createdAt), entry.Action, entry.PID, entry.TargetName, entry.TargetCmdline,
+		entry.Params, entry.ClientAddr, entry.Outcome, entry.Error,
+	)
+	if err != nil {
+		return fmt.Errorf("не удалось сохранить запись аудита (%s): %w", entry.Action, err)
+	}
+	return nil
+}
+
+// QueryAuditEntries возвращает страницу журнала по фильтру (новые первыми) и общее число подходящих записей.
+func QueryAuditEntries(sqlDB *sql.DB, filter AuditFilter) ([]models.AuditEntry, int64, error) {
+	var conditions []string
+	var args []any
+
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if filter.PID > 0 {
+		conditions = append(conditions, "pid = ?")
+		args = append(args, filter.PID)
+	}
+	if filter.Outcome != "" {
+		conditions = append(conditions, "outcome = ?")
+		args = append(args, filter.Outcome)
+	}
+	if filter.Client != "" {
+		conditions = append(conditions, "client_addr LIKE ? ESCAPE '\\'")
+		args = append(args, "%"+escapeLike(filter.Client)+"%")
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, FormatTime(filter.From))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "created_at <= ?")
+		args = append(args, FormatTime(filter.To))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	if err := sqlDB.QueryRow(`SELECT COUNT(*) FROM audit_log`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("не удалось посчитать записи аудита: %w", err)
+	}
+
+	query := `SELECT id, created_at, action, pid, target_name, target_cmdline, params, client_addr, outcome, error
+		FROM audit_log` + where + ` ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`
+	rows, err := sqlDB.Query(query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("не удалось получить записи аудита: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]models.AuditEntry, 0)
+	for rows.Next() {
+		var entry models.AuditEntry
+		var createdAt time.Time
+		if err := rows.Scan(&entry.ID, &createdAt, &entry.Action, &entry.PID, &entry.TargetName, &entry.TargetCmdline,
+			&entry.Params, &entry.ClientAddr, &entry.Outcome, &entry.Error); err != nil {
+			return nil, 0, fmt.Errorf("не удалось прочитать запись аудита: %w", err)
+		}
+		entry.Timestamp = LocalTime(createdAt)
+		result = append(result, entry)
+	}
+	return result, total, rows.Err()
+}
@@ -0,0 +1,133 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/models"
+)
+
+// Разрешения агрегатов истории метрик, в секундах.
+const (
+	ResolutionMinute int64 = 60
+	ResolutionHour   int64 = 3600
+)
+
+// MetricSample - один сырой замер метрики.
+type MetricSample struct {
+	Metric string
+	TS     int64
+	Value  float64
+}
+
+// InsertMetricSamples сохраняет пачку сырых замеров одной транзакцией.
+func InsertMetricSamples(sqlDB *sql.DB, samples []MetricSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	tx, err := sqlDB.Begin()
+	if err != nil {
+		return fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO metric_samples (metric, ts, value) VALUES (?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("не удалось подготовить запрос: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, sample := range samples {
+		if _, err := stmt.Exec(sample.Metric, sample.TS, sample.Value); err != nil {
+			return fmt.Errorf("не удалось сохранить замер %s: %w", sample.Metric, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// RollupSamples агрегирует сырые замеры из [from, to) в минутные корзины.
+// Повторный вызов для того же интервала перезаписывает корзины, поэтому безопасен.
+func RollupSamples(sqlDB *sql.DB, from, to int64) error {
+	_, err := sqlDB.Exec(
+		`INSERT OR REPLACE INTO metric_rollups (metric, resolution, bucket, min_value, avg_value, max_value, samples)
+		 SELECT metric, ?1, (ts / ?1) * ?1, MIN(value), AVG(value), MAX(value), COUNT(*)
+		 FROM metric_samples WHERE ts >= ?2 AND ts < ?3
+		 GROUP BY metric, ts / ?1`,
+		ResolutionMinute, from, to,
+	)
+	if err != nil {
+		return fmt.Errorf("не удалось агрегировать замеры в минутные корзины: %w", err)
+	}
+	return nil
+}
+
+// RollupMinutes агрегирует минутные корзины из [from, to) в часовые.
+// Среднее взвешивается по числу замеров в каждой минутной корзине.
+func RollupMinutes(sqlDB *sql.DB, from, to int64) error {
+	_, err := sqlDB.Exec(
+		`INSERT OR REPLACE INTO metric_rollups (metric, resolution, bucket, min_value, avg_value, max_value, samples)
+		 SELECT metric, ?1, (bucket / ?1) * ?1, MIN(min_value), SUM(avg_value * samples) / SUM(samples), MAX(max_value), SUM(samples)
+		 FROM metric_rollups WHERE resolution = ?2 AND bucket >= ?3 AND bucket < ?4
+		 GROUP BY metric, bucket / ?1`,
+		ResolutionHour, ResolutionMinute, from, to,
+	)
+	if err != nil {
+		return fmt.Errorf("не удалось агрегировать минутные корзины в часовые: %w", err)
+	}
+	return nil
+}
+
+// PruneHistory удаляет сырые замеры, минутные и часовые корзины старше указанных границ.
+func PruneHistory(sqlDB *sql.DB, rawBefore, minuteBefore, hourBefore int64) error {
+	if _, err := sqlDB.Exec(`DELETE FROM metric_samples WHERE ts < ?`, rawBefore); err != nil {
+		return fmt.Errorf("не удалось удалить устаревшие замеры: %w", err)
+	}
+	if _, err := sqlDB.Exec(`DELETE FROM metric_rollups WHERE resolution = ? AND bucket < ?`, ResolutionMinute, minuteBefore); err != nil {
+		return fmt.Errorf("не удалось удалить устаревшие минутные корзины: %w", err)
+	}
+	if _, err := sqlDB.Exec(`DELETE FROM metric_rollups WHERE resolution = ? AND bucket < ?`, ResolutionHour, hourBefore); err != nil {
+		return fmt.Errorf("не удалось удалить устаревшие часовые корзины: %w", err)
+	}
+	return nil
+}
+
+// QueryHistory возвращает точки метрики в [from, to) с шагом step секунд.
+// resolution = 0 читает сырые замеры, иначе - корзины указанного разрешения.
+func QueryHistory(sqlDB *sql.DB, metric string, resolution, from, to, step int64) ([]models.HistoryPoint, error) {
+	var rows *sql.Rows
+	var err error
+
+	if resolution == 0 {
+		rows, err = sqlDB.Query(
+			`SELECT (ts / ?1) * ?1 AS b, MIN(value), AVG(value), MAX(value)
+			 FROM metric_samples WHERE metric = ?2 AND ts >= ?3 AND ts < ?4
+			 GROUP BY b ORDER BY b`,
+			step, metric, from, to,
+		)
+	} else {
+		rows, err = sqlDB.Query(
+			`SELECT (bucket / ?1) * ?1 AS b, MIN(min_value), SUM(avg_value * samples) / SUM(samples), MAX(max_value)
+			 FROM metric_rollups WHERE metric = ?2 AND resolution = ?3 AND bucket >= ?4 AND bucket < ?5
+			 GROUP BY b ORDER BY b`,
+			step, metric, resolution, from, to,
+		)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить историю %s: %w", metric, err)
+	}
+	defer rows.Close()
+
+	result := make([]models.HistoryPoint, 0)
+	for rows.Next() {
+		var point models.HistoryPoint
+		if err := rows.Scan(&point.TS, &point.Min, &point.Avg, &point.Max); err != nil {
+			return nil, fmt.Errorf("не удалось прочитать точку истории: %w", err)
+		}
+		point.Timestamp = time.Unix(point.TS, 0).Format(TimeLayout)
+		result = append(result, point)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,149 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/mattn/go-sqlite3"
+)
+
+var (
+	// ErrIntegrityRuleNotFound возвращается, если правила с указанным id нет.
+	ErrIntegrityRuleNotFound = errors.New("правило целостности не найдено")
+	// ErrIntegrityRuleExists возвращается при добавлении правила, которое уже есть в списке.
+	ErrIntegrityRuleExists = errors.New("такое правило уже есть в списке")
+)
+
+// InsertIntegrityRule сохраняет правило списка и возвращает его с ID и временем создания.
+func InsertIntegrityRule(sqlDB *sql.DB, rule models.IntegrityRule, createdAt time.Time) (models.IntegrityRule, error) {
+	res, err := sqlDB.Exec(
+		`INSERT INTO integrity_rules (list, kind, value, comment, created_at) VALUES (?, ?, ?, ?, ?)`,
+		rule.List, rule.Kind, rule.Value, rule.Comment, FormatTime(createdAt),
+	)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return rule, ErrIntegrityRuleExists
+		}
+		return rule, fmt.Errorf("не удалось сохранить правило целостности: %w", err)
+	}
+
+	rule.ID, err = res.LastInsertId()
+	if err != nil {
+		return rule, fmt.Errorf("не удалось получить id правила целостности: %w", err)
+	}
+	rule.Timestamp = createdAt.Format(TimeLayout)
+	return rule, nil
+}
+
+// DeleteIntegrityRule удаляет правило по id или возвращает ErrIntegrityRuleNotFound.
+func DeleteIntegrityRule(sqlDB *sql.DB, id int64) error {
+	res, err := sqlDB.Exec(`DELETE FROM integrity_rules WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("не удалось удалить правило целостности %d: %w", id, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrIntegrityRuleNotFound
+	}
+	return nil
+}
+
+// ListIntegrityRules возвращает все правила списков в порядке добавления.
+func ListIntegrityRules(sqlDB *sql.DB) ([]models.IntegrityRule, error) {
+	rows, err := sqlDB.Query(`SELECT id, list, kind, value, comment, created_at FROM integrity_rules ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить правила целостности: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]models.IntegrityRule, 0)
+	for rows.Next() {
+		var rule models.IntegrityRule
+		var createdAt time.Time
+		if err := rows.Scan(&rule.ID, &rule.List, &rule.Kind, &rule.Value, &rule.Comment, &createdAt); err != nil {
+			return nil, fmt.Errorf("не удалось прочитать правило целостности: %w", err)
+		}
+		rule.Timestamp = LocalTime(createdAt)
+		result = append(result, rule)
+	}
+	return result, rows.Err()
+}
+
+// InsertIntegrityEvent сохраняет событие о новом неизвестном хэше. Если событие по этому хэшу
+// уже есть, ничего не меняется и возвращается false.
+func InsertIntegrityEvent(sqlDB *sql.DB, event *models.IntegrityEvent, observedAt time.Time) (bool, error) {
+	res, err := sqlDB.Exec(
+		`INSERT OR IGNORE INTO integrity_events (observed_at, sha256, exe, pid, name, username) VALUES (?, ?, ?, ?, ?, ?)`,
+		FormatTime(observedAt), event.SHA256, event.Exe, event.PID, event.Name, event.Username,
+	)
+	if err != nil {
+		return false, fmt.Errorf("не удалось сохранить событие целостности (%s): %w", event.Exe, err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	if id, err := res.LastInsertId(); err == nil {
+		event.ID = id
+	}
+	event.Timestamp = observedAt.Format(TimeLayout)
+	return true, nil
+}
+
+// IntegrityEventHashes возвращает хэши, по которым уже есть события.
+func IntegrityEventHashes(sqlDB *sql.DB) (map[string]bool, error) {
+	rows, err := sqlDB.Query(`SELECT sha256 FROM integrity_events`)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить хэши событий целостности: %w", err)
+	}
+	defer rows.Close()
+
+	hashes := make(map[string]bool)
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, fmt.Errorf("не удалось прочитать хэш события целостности: %w", err)
+		}
+		hashes[hash] = true
+	}
+	return hashes, rows.Err()
+}
+
+// QueryIntegrityEvents возвращает события о неизвестных хэшах за интервал, новые первыми.
+func QueryIntegrityEvents(sqlDB *sql.DB, from, to time.Time, limit int) ([]models.IntegrityEvent, error) {
+	query := `SELECT id, observed_at, sha256, exe, pid, name, username FROM integrity_events WHERE 1 = 1`
+	var args []any
+	if !from.IsZero() {
+		query += " AND observed_at >= ?"
+		args = append(args, FormatTime(from))
+	}
+	if !to.IsZero() {
+		query += " AND observed_at <= ?"
+		args = append(args, FormatTime(to))
+	}
+	query += " ORDER BY observed_at DESC, id DESC"
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := sqlDB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить события целостности: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]models.IntegrityEvent, 0)
+	for rows.Next() {
+		var e models.IntegrityEvent
+		var observedAt time.Time
+		if err := rows.Scan(&e.ID, &observedAt, &e.SHA256, &e.Exe, &e.PID, &e.Name, &e.Username); err != nil {
+			return nil, fmt.Errorf("не удалось прочитать событие целостности: %w", err)
+		}
+		e.Timestamp = LocalTime(observedAt)
+		result = append(result, e)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// ErrDatabaseNewer возвращается, если база создана более новой версией агента.
+var ErrDatabaseNewer = errors.New("схема базы данных новее, чем поддерживает агент")
+
+// Migration - одна версия схемы базы. SQL выполняется в транзакции вместе с записью в schema_version.
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+const schemaVersionSQL = `
+CREATE TABLE IF NOT EXISTS schema_version (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+`
+
+// LatestVersion возвращает версию схемы, которую поддерживает эта сборка агента.
+func LatestVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// SchemaVersion возвращает текущую версию схемы базы (0 - миграции не применялись).
+func SchemaVersion(sqlDB *sql.DB) (int, error) {
+	var version int
+	if err := sqlDB.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("не удалось прочитать версию схемы: %w", err)
+	}
+	return version, nil
+}
+
+// Migrate приводит схему базы к последней версии. Каждая миграция применяется в отдельной
+// транзакции: при ошибке база остаётся на предыдущей версии. Если база новее агента,
+// возвращается ErrDatabaseNewer и база не изменяется.
+func Migrate(sqlDB *sql.DB) error {
+	if err := validateMigrations(); err != nil {
+		return err
+	}
+
+	if _, err := sqlDB.Exec(schemaVersionSQL); err != nil {
+		return fmt.Errorf("не удалось создать таблицу schema_version: %w", err)
+	}
+
+	current, err := SchemaVersion(sqlDB)
+	if err != nil {
+		return err
+	}
+
+	latest := LatestVersion()
+	if current > latest {
+		return fmt.Errorf("%w: версия базы %d, поддерживается до %d - обновите агент", ErrDatabaseNewer, current, latest)
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		if err := applyMigration(sqlDB, m); err != nil {
+			return err
+		}
+		log.Printf("Применена миграция базы %d: %s", m.Version, m.Name)
+	}
+
+	return nil
+}
+
+func applyMigration(sqlDB *sql.DB, m Migration) error {
+	tx, err := sqlDB.Begin()
+	if err != nil {
+		return fmt.Errorf("миграция %d (%s): не удалось начать транзакцию: %w", m.Version, m.Name, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.SQL); err != nil {
+		return fmt.Errorf("миграция %d (%s): %w", m.Version, m.Name, err)
+	}
+	if _, err := tx.Exec(`INSERT INTO schema_version (version, name) VALUES (?, ?)`, m.Version, m.Name); err != nil {
+		return fmt.Errorf("миграция %d (%s): не удалось записать версию: %w", m.Version, m.Name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("миграция %d (%s): не удалось зафиксировать транзакцию: %w", m.Version, m.Name, err)
+	}
+	return nil
+}
+
+// validateMigrations проверяет, что версии идут подряд начиная с 1.
+func validateMigrations() error {
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return fmt.Errorf("нарушен порядок миграций: на позиции %d версия %d, ожидалась %d", i, m.Version, i+1)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// DefaultPath - файл базы агента: его создаёт сервер, а CLI открывает уже существующим.
+const DefaultPath = "./monitor.db"
+
+// ErrDatabaseMissing возвращается OpenExisting, если файла базы нет.
+var ErrDatabaseMissing = errors.New("база данных не найдена")
+
+// OpenExisting открывает существующую базу и приводит её схему к последней версии.
+// В отличие от открытия сервером, отсутствующий файл не создаётся: возвращается ErrDatabaseMissing.
+func OpenExisting(path string) (*sql.DB, error) {
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrDatabaseMissing, path)
+		}
+		return nil, err
+	}
+
+	// mode=rw не даёт драйверу создать файл, если его удалили между проверкой и открытием.
+	sqlDB, err := sql.Open("sqlite3", "file:"+path+"?mode=rw")
+	if err != nil {
+		return nil, err
+	}
+	if err := Migrate(sqlDB); err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+	return sqlDB, nil
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/models"
+)
+
+// ProcessEventFilter задаёт условия выборки событий процессов. Нулевые поля не участвуют в фильтрации.
+type ProcessEventFilter struct {
+	PID   int32
+	Name  string
+	Type  string
+	From  time.Time
+	To    time.Time
+	Limit int
+}
+
+// InsertProcessEvents сохраняет события одного снимка одной транзакцией и проставляет им ID.
+func InsertProcessEvents(sqlDB *sql.DB, observedAt time.Time, events []models.ProcessEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	tx, err := sqlDB.Begin()
+	if err != nil {
+		return fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(
+		`INSERT INTO process_events (event_type, observed_at, pid, create_time, name, exe, cmdline, username, parent_pid, lifetime_sec)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+	)
+	if err != nil {
+		return fmt.Errorf("не удалось подготовить запрос: %w", err)
+	}
+	defer stmt.Close()
+
+	at := FormatTime(observedAt)
+	for i := range events {
+		e := &events[i]
+		res, err := stmt.Exec(e.Type, at, e.PID, e.CreateTime, e.Name, e.Exe, e.Cmdline, e.Username, e.ParentPID, e.LifetimeSec)
+		if err != nil {
+			return fmt.Errorf("не удалось сохранить событие PID=%d: %w", e.PID, err)
+		}
+		if id, err := res.LastInsertId(); err == nil {
+			e.ID = id
+		}
+	}
+
+	return tx.Commit()
+}
+
+// QueryProcessEvents возвращает события по фильтру, новые первыми. Name ищется как подстрока.
+func QueryProcessEvents(sqlDB *sql.DB, filter ProcessEventFilter) ([]models.ProcessEvent, error) {
+	var conditions []string
+	var args []any
+
+	if filter.PID > 0 {
+		conditions = append(conditions, "pid = ?")
+		args = append(args, filter.PID)
+	}
+	if filter.Name != "" {
+		conditions = append(conditions, "name LIKE ? ESCAPE '\\'")
+		args = append(args, "%"+escapeLike(filter.Name)+"%")
+	}
+	if filter.Type != "" {
+		conditions = append(conditions, "event_type = ?")
+		args = append(args, filter.Type)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "observed_at >= ?")
+		args = append(args, FormatTime(filter.From))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "observed_at <= ?")
+		args = append(args, FormatTime(filter.To))
+	}
+
+	query := `SELECT id, event_type, observed_at, pid, create_time, name, exe, cmdline, username, parent_pid, lifetime_sec
+		FROM process_events`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY observed_at DESC, id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := sqlDB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить события процессов: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]models.ProcessEvent, 0)
+	for rows.Next() {
+		var e models.ProcessEvent
+		var observedAt time.Time
+		if err := rows.Scan(&e.ID, &e.Type, &observedAt, &e.PID, &e.CreateTime, &e.Name, &e.Exe, &e.Cmdline,
+			&e.Username, &e.ParentPID, &e.LifetimeSec); err != nil {
+			return nil, fmt.Errorf("не удалось прочитать событие процесса: %w", err)
+		}
+		e.Timestamp = LocalTime(observedAt)
+		result = append(result, e)
+	}
+	return result, rows.Err()
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
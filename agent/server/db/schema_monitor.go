@@ -1,7 +1,20 @@
-// схема на будущее, когда буду реализовывать хранение статистики
+// схема базы мониторинга в виде пронумерованных миграций (применяются в Migrate);
+// таблицу spikes заполняет services.SpikeRecorder, sessions и session_samples - services.SessionRecorder,
+// metric_samples и metric_rollups (время в Unix-секундах) - services.HistoryRecorder,
+// process_events - services.LifecycleTracker, audit_log - services.AuditLog,
+// integrity_rules и integrity_events - services.IntegrityChecker
 package db
 
-const SchemaSQL = `
+// migrations - все миграции схемы по возрастанию версии. Уже выпущенные миграции не меняются:
+// любое изменение схемы добавляется новой миграцией в конец списка.
+//
+// Миграция 1 использует IF NOT EXISTS, потому что таблица spikes создавалась ещё до появления
+// schema_version и уже может существовать в базах прежних версий агента.
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "spikes",
+		SQL: `
 CREATE TABLE IF NOT EXISTS spikes (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     detected_at DATETIME DEFAULT (datetime('now')),
@@ -17,4 +30,127 @@ CREATE TABLE IF NOT EXISTS spikes (
 CREATE INDEX IF NOT EXISTS idx_pid ON spikes(pid);
 CREATE INDEX IF NOT EXISTS idx_detected_at ON spikes(detected_at);
 CREATE INDEX IF NOT EXISTS idx_reason ON spikes(reason);
-`
+`,
+	},
+	{
+		Version: 2,
+		Name:    "sessions",
+		SQL: `
+CREATE TABLE sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL DEFAULT '',
+    cpu_threshold REAL NOT NULL DEFAULT 0,
+    interval_sec INTEGER NOT NULL,
+    started_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    stopped_at DATETIME
+);
+
+CREATE TABLE session_samples (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id INTEGER NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    sampled_at DATETIME NOT NULL,
+    pid INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    cpu_percent REAL,
+    memory_percent REAL,
+    memory_rss INTEGER
+);
+
+CREATE INDEX idx_session_samples_pid ON session_samples(session_id, pid, sampled_at);
+`,
+	},
+	{
+		Version: 3,
+		Name:    "metric history",
+		SQL: `
+CREATE TABLE metric_samples (
+    metric TEXT NOT NULL,
+    ts INTEGER NOT NULL,
+    value REAL NOT NULL
+);
+
+CREATE INDEX idx_metric_samples ON metric_samples(metric, ts);
+
+CREATE TABLE metric_rollups (
+    metric TEXT NOT NULL,
+    resolution INTEGER NOT NULL,
+    bucket INTEGER NOT NULL,
+    min_value REAL NOT NULL,
+    avg_value REAL NOT NULL,
+    max_value REAL NOT NULL,
+    samples INTEGER NOT NULL,
+    PRIMARY KEY (metric, resolution, bucket)
+);
+`,
+	},
+	{
+		Version: 4,
+		Name:    "process events",
+		SQL: `
+CREATE TABLE process_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_type TEXT NOT NULL,
+    observed_at DATETIME NOT NULL,
+    pid INTEGER NOT NULL,
+    create_time INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    exe TEXT NOT NULL DEFAULT '',
+    cmdline TEXT NOT NULL DEFAULT '',
+    username TEXT NOT NULL DEFAULT '',
+    parent_pid INTEGER NOT NULL DEFAULT 0,
+    lifetime_sec INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_process_events_observed_at ON process_events(observed_at);
+CREATE INDEX idx_process_events_pid ON process_events(pid);
+`,
+	},
+	{
+		Version: 5,
+		Name:    "audit log",
+		SQL: `
+CREATE TABLE audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME NOT NULL,
+    action TEXT NOT NULL,
+    pid INTEGER NOT NULL DEFAULT 0,
+    target_name TEXT NOT NULL DEFAULT '',
+    target_cmdline TEXT NOT NULL DEFAULT '',
+    params TEXT NOT NULL DEFAULT '',
+    client_addr TEXT NOT NULL DEFAULT '',
+    outcome TEXT NOT NULL,
+    error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_audit_log_created_at ON audit_log(created_at);
+CREATE INDEX idx_audit_log_action ON audit_log(action);
+`,
+	},
+	{
+		Version: 6,
+		Name:    "executable integrity",
+		SQL: `
+CREATE TABLE integrity_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    list TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    value TEXT NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    UNIQUE (list, kind, value)
+);
+
+CREATE TABLE integrity_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    observed_at DATETIME NOT NULL,
+    sha256 TEXT NOT NULL UNIQUE,
+    exe TEXT NOT NULL,
+    pid INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    username TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_integrity_events_observed_at ON integrity_events(observed_at);
+`,
+	},
+}
@@ -0,0 +1,175 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/models"
+)
+
+// ErrSessionNotFound возвращается, если сессии с указанным id нет.
+var ErrSessionNotFound = errors.New("сессия не найдена")
+
+const sessionColumns = `id, name, cpu_threshold, interval_sec, started_at, stopped_at`
+
+// CreateSession сохраняет новую активную сессию записи.
+func CreateSession(sqlDB *sql.DB, name string, cpuThreshold float64, intervalSec int, startedAt time.Time) (models.Session, error) {
+	res, err := sqlDB.Exec(
+		`INSERT INTO sessions (name, cpu_threshold, interval_sec, started_at) VALUES (?, ?, ?, ?)`,
+		name, cpuThreshold, intervalSec, FormatTime(startedAt),
+	)
+	if err != nil {
+		return models.Session{}, fmt.Errorf("не удалось создать сессию: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return models.Session{}, fmt.Errorf("не удалось получить id сессии: %w", err)
+	}
+	return GetSession(sqlDB, id)
+}
+
+// StopSession помечает сессию остановленной. Уже остановленная сессия не меняется.
+func StopSession(sqlDB *sql.DB, id int64, stoppedAt time.Time) error {
+	_, err := sqlDB.Exec(
+		`UPDATE sessions SET stopped_at = ? WHERE id = ? AND stopped_at IS NULL`,
+		FormatTime(stoppedAt), id,
+	)
+	if err != nil {
+		return fmt.Errorf("не удалось остановить сессию %d: %w", id, err)
+	}
+	return nil
+}
+
+// CloseDanglingSessions останавливает сессии, оставшиеся активными после аварийного завершения агента.
+func CloseDanglingSessions(sqlDB *sql.DB, stoppedAt time.Time) error {
+	_, err := sqlDB.Exec(`UPDATE sessions SET stopped_at = ? WHERE stopped_at IS NULL`, FormatTime(stoppedAt))
+	if err != nil {
+		return fmt.Errorf("не удалось закрыть незавершённые сессии: %w", err)
+	}
+	return nil
+}
+
+// GetSession возвращает сессию по id или ErrSessionNotFound.
+func GetSession(sqlDB *sql.DB, id int64) (models.Session, error) {
+	row := sqlDB.QueryRow(`SELECT `+sessionColumns+` FROM sessions WHERE id = ?`, id)
+	session, err := scanSession(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Session{}, ErrSessionNotFound
+	}
+	return session, err
+}
+
+// ListSessions возвращает все сессии, новые первыми.
+func ListSessions(sqlDB *sql.DB) ([]models.Session, error) {
+	rows, err := sqlDB.Query(`SELECT ` + sessionColumns + ` FROM sessions ORDER BY id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить сессии: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]models.Session, 0)
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, session)
+	}
+	return result, rows.Err()
+}
+
+// InsertSessionSamples сохраняет снимок процессов сессии одной транзакцией.
+func InsertSessionSamples(sqlDB *sql.DB, sessionID int64, sampledAt time.Time, procs []models.ProcessInfo) error {
+	if len(procs) == 0 {
+		return nil
+	}
+
+	tx, err := sqlDB.Begin()
+	if err != nil {
+		return fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(
+		`INSERT INTO session_samples (session_id, sampled_at, pid, name, cpu_percent, memory_percent, memory_rss)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+	)
+	if err != nil {
+		return fmt.Errorf("не удалось подготовить запрос: %w", err)
+	}
+	defer stmt.Close()
+
+	at := FormatTime(sampledAt)
+	for _, p := range procs {
+		if _, err := stmt.Exec(sessionID, at, p.PID, p.Name, p.CPUPercent, p.MemoryPercent, int64(p.MemoryRSS)); err != nil {
+			return fmt.Errorf("не удалось сохранить замер PID=%d: %w", p.PID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// QuerySessionSamples возвращает временной ряд процесса в сессии в хронологическом порядке.
+func QuerySessionSamples(sqlDB *sql.DB, sessionID int64, pid int32, from, to time.Time) ([]models.SessionSample, error) {
+	query := `SELECT sampled_at, name, COALESCE(cpu_percent, 0), COALESCE(memory_percent, 0), COALESCE(memory_rss, 0)
+		FROM session_samples WHERE session_id = ? AND pid = ?`
+	args := []any{sessionID, pid}
+
+	if !from.IsZero() {
+		query += " AND sampled_at >= ?"
+		args = append(args, FormatTime(from))
+	}
+	if !to.IsZero() {
+		query += " AND sampled_at <= ?"
+		args = append(args, FormatTime(to))
+	}
+	query += " ORDER BY sampled_at"
+
+	rows, err := sqlDB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить историю процесса: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]models.SessionSample, 0)
+	for rows.Next() {
+		var sample models.SessionSample
+		var sampledAt time.Time
+		var rss int64
+		if err := rows.Scan(&sampledAt, &sample.Name, &sample.CPUPercent, &sample.MemoryPercent, &rss); err != nil {
+			return nil, fmt.Errorf("не удалось прочитать замер: %w", err)
+		}
+		sample.Timestamp = LocalTime(sampledAt)
+		sample.MemoryRSS = uint64(rss)
+		result = append(result, sample)
+	}
+	return result, rows.Err()
+}
+
+// rowScanner - общий интерфейс *sql.Row и *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSession(row rowScanner) (models.Session, error) {
+	var session models.Session
+	var startedAt time.Time
+	var stoppedAt sql.NullTime
+	if err := row.Scan(&session.ID, &session.Name, &session.CPUThreshold, &session.IntervalSec, &startedAt, &stoppedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return session, err
+		}
+		return session, fmt.Errorf("не удалось прочитать сессию: %w", err)
+	}
+
+	session.StartedAt = LocalTime(startedAt)
+	if stoppedAt.Valid {
+		session.StoppedAt = LocalTime(stoppedAt.Time)
+	} else {
+		session.Active = true
+	}
+	return session, nil
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/models"
+)
+
+// SpikeFilter задаёт условия выборки всплесков. Нулевые поля не участвуют в фильтрации.
+type SpikeFilter struct {
+	PID    int32
+	Reason string
+	From   time.Time
+	To     time.Time
+	Limit  int
+}
+
+// InsertSpike сохраняет завершённый эпизод всплеска.
+func InsertSpike(sqlDB *sql.DB, spike models.Spike, detectedAt time.Time) error {
+	_, err := sqlDB.Exec(
+		`INSERT INTO spikes (detected_at, pid, name, cpu_percent, memory_rss, memory_vms, duration_sec, reason)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		FormatTime(detectedAt), spike.PID, spike.Name, spike.CPUPercent,
+		int64(spike.MemoryRSS), int64(spike.MemoryVMS), spike.DurationSec, spike.Reason,
+	)
+	if err != nil {
+		return fmt.Errorf("не удалось сохранить всплеск PID=%d: %w", spike.PID, err)
+	}
+	return nil
+}
+
+// QuerySpikes возвращает всплески по фильтру, новые первыми.
+func QuerySpikes(sqlDB *sql.DB, filter SpikeFilter) ([]models.Spike, error) {
+	var conditions []string
+	var args []any
+
+	if filter.PID > 0 {
+		conditions = append(conditions, "pid = ?")
+		args = append(args, filter.PID)
+	}
+	if filter.Reason != "" {
+		conditions = append(conditions, "reason = ?")
+		args = append(args, filter.Reason)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "detected_at >= ?")
+		args = append(args, FormatTime(filter.From))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "detected_at <= ?")
+		args = append(args, FormatTime(filter.To))
+	}
+
+	query := `SELECT id, detected_at, pid, name, COALESCE(cpu_percent, 0), COALESCE(memory_rss, 0),
+		COALESCE(memory_vms, 0), duration_sec, reason FROM spikes`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY detected_at DESC, id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := sqlDB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить всплески: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]models.Spike, 0)
+	for rows.Next() {
+		var spike models.Spike
+		var detectedAt time.Time
+		var rss, vms int64
+		if err := rows.Scan(&spike.ID, &detectedAt, &spike.PID, &spike.Name, &spike.CPUPercent,
+			&rss, &vms, &spike.DurationSec, &spike.Reason); err != nil {
+			return nil, fmt.Errorf("не удалось прочитать всплеск: %w", err)
+		}
+		spike.MemoryRSS = uint64(rss)
+		spike.MemoryVMS = uint64(vms)
+		spike.DetectedAt = LocalTime(detectedAt)
+		result = append(result, spike)
+	}
+	return result, rows.Err()
+}
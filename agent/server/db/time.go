@@ -0,0 +1,17 @@
+package db
+
+import "time"
+
+// TimeLayout - формат хранения дат в SQLite (совпадает с datetime('now'), всегда UTC).
+const TimeLayout = "2006-01-02 15:04:05"
+
+// FormatTime переводит время в формат хранения (UTC).
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(TimeLayout)
+}
+
+// LocalTime форматирует прочитанное из базы время в локальном часовом поясе,
+// в том же виде, что используется в остальных ответах API.
+func LocalTime(t time.Time) string {
+	return t.Local().Format(TimeLayout)
+}
@@ -0,0 +1,126 @@
+package getmetrics
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// cgroupMountCandidates - возможные точки монтирования иерархии cgroup v2:
+// в unified-режиме это /sys/fs/cgroup, в гибридном - /sys/fs/cgroup/unified.
+var cgroupMountCandidates = []string{"/sys/fs/cgroup", "/sys/fs/cgroup/unified"}
+
+// containerIDPattern - идентификатор контейнера Docker, containerd, CRI-O и Podman (64 hex-символа).
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// readProcessCgroup возвращает путь cgroup процесса из /proc/<pid>/cgroup.
+func readProcessCgroup(pid int32) (string, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return "", err
+	}
+	return parseCgroupFile(data), nil
+}
+
+// parseCgroupFile выбирает путь cgroup из содержимого /proc/<pid>/cgroup. Основной источник -
+// строка cgroup v2 ("0::/path"). В гибридном режиме она часто равна "/", тогда берётся
+// первый более конкретный путь из иерархий v1, начиная с name=systemd.
+func parseCgroupFile(data []byte) string {
+	var unified, systemd, other string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+
+		hierarchy, controllers, cgroupPath := parts[0], parts[1], parts[2]
+		switch {
+		case hierarchy == "0" && controllers == "":
+			unified = cgroupPath
+		case cgroupPath == "/":
+		case controllers == "name=systemd" && systemd == "":
+			systemd = cgroupPath
+		case other == "":
+			other = cgroupPath
+		}
+	}
+
+	for _, candidate := range []string{unified, systemd, other} {
+		if candidate != "" && candidate != "/" {
+			return candidate
+		}
+	}
+	return unified
+}
+
+// describeCgroup извлекает из пути cgroup идентификатор контейнера и unit или slice systemd.
+// Например, "/system.slice/docker-<id>.scope" даёт контейнер <id> и unit "docker-<id>.scope",
+// а "/system.slice/nginx.service" - только unit "nginx.service".
+func describeCgroup(cgroupPath string) (containerID, unit string) {
+	if matches := containerIDPattern.FindAllString(cgroupPath, -1); len(matches) > 0 {
+		containerID = matches[len(matches)-1]
+	}
+
+	var slice string
+	for _, element := range strings.Split(cgroupPath, "/") {
+		switch path.Ext(element) {
+		case ".service", ".scope", ".socket", ".mount", ".swap":
+			unit = element
+		case ".slice":
+			slice = element
+		}
+	}
+	if unit == "" {
+		unit = slice
+	}
+	return containerID, unit
+}
+
+// cgroupMount возвращает точку монтирования cgroup v2 или пустую строку, если её нет.
+func cgroupMount() string {
+	for _, candidate := range cgroupMountCandidates {
+		if _, err := os.Stat(path.Join(candidate, "cgroup.controllers")); err == nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// readCgroupCPUUsage читает суммарное CPU-время группы в микросекундах из cpu.stat (поле usage_usec).
+func readCgroupCPUUsage(dir string) (uint64, error) {
+	data, err := os.ReadFile(path.Join(dir, "cpu.stat"))
+	if err != nil {
+		return 0, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), " ")
+		if ok && name == "usage_usec" {
+			return strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		}
+	}
+	return 0, fmt.Errorf("в %s/cpu.stat нет usage_usec", dir)
+}
+
+// readCgroupUint читает числовой файл группы (memory.current, memory.max).
+// Значение "max" (без ограничения) возвращается как 0.
+func readCgroupUint(dir, name string) (uint64, error) {
+	data, err := os.ReadFile(path.Join(dir, name))
+	if err != nil {
+		return 0, err
+	}
+
+	value := strings.TrimSpace(string(data))
+	if value == "max" {
+		return 0, nil
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
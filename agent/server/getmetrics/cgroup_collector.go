@@ -0,0 +1,136 @@
+package getmetrics
+
+import (
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/shirou/gopsutil/v4/cpu"
+)
+
+// Источники данных сводки по cgroup.
+const (
+	CgroupSourceCgroup    = "cgroup"    // Файлы cgroup v2
+	CgroupSourceProcesses = "processes" // Суммы по процессам группы
+)
+
+// cgroupUsage - CPU-время группы на момент предыдущего замера.
+type cgroupUsage struct {
+	usageUsec uint64
+	at        time.Time
+}
+
+// CgroupCollector собирает сводку по cgroup, в которых есть процессы снимка.
+// CPU% группы считается по приросту usage_usec из cpu.stat между вызовами.
+type CgroupCollector struct {
+	mu     sync.Mutex
+	numCPU float64
+	prev   map[string]cgroupUsage
+}
+
+// NewCgroupCollector создаёт сборщик сводки по cgroup.
+func NewCgroupCollector() *CgroupCollector {
+	numCPU := runtime.NumCPU()
+	if counts, err := cpu.Counts(true); err == nil && counts > 0 {
+		numCPU = counts
+	}
+
+	return &CgroupCollector{
+		numCPU: float64(numCPU),
+		prev:   make(map[string]cgroupUsage),
+	}
+}
+
+// Collect группирует процессы по cgroup и дополняет группы данными из файлов cgroup v2.
+// Группа, впервые увиденная в этом вызове, получает CPU 0% - для неё ещё нет точки отсчёта.
+// Результат упорядочен по убыванию CPU.
+func (c *CgroupCollector) Collect(procs []models.ProcessInfo) []models.CgroupStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	groups := make(map[string]*models.CgroupStats)
+	processCPU := make(map[string]float64)
+	for _, p := range procs {
+		if p.Cgroup == "" {
+			continue
+		}
+
+		group, ok := groups[p.Cgroup]
+		if !ok {
+			group = &models.CgroupStats{
+				Path:        p.Cgroup,
+				ContainerID: p.ContainerID,
+				SystemdUnit: p.SystemdUnit,
+				PIDs:        []int32{},
+			}
+			groups[p.Cgroup] = group
+		}
+		group.Processes++
+		group.PIDs = append(group.PIDs, p.PID)
+		group.ProcessesRSS += p.MemoryRSS
+		processCPU[p.Cgroup] += p.CPUPercent
+	}
+
+	mount := cgroupMount()
+	now := time.Now()
+	next := make(map[string]cgroupUsage, len(groups))
+	result := make([]models.CgroupStats, 0, len(groups))
+
+	for cgroupPath, group := range groups {
+		group.Source = CgroupSourceProcesses
+		group.CPUPercent = processCPU[cgroupPath]
+		group.MemoryCurrent = group.ProcessesRSS
+
+		if mount != "" && !strings.Contains(cgroupPath, "..") {
+			dir := path.Join(mount, cgroupPath)
+			usage, cpuErr := readCgroupCPUUsage(dir)
+			memoryCurrent, memErr := readCgroupUint(dir, "memory.current")
+
+			if cpuErr == nil && memErr == nil {
+				group.Source = CgroupSourceCgroup
+				group.MemoryCurrent = memoryCurrent
+				group.MemoryMax, _ = readCgroupUint(dir, "memory.max")
+				group.CPUPercent = 0
+
+				if prev, ok := c.prev[cgroupPath]; ok && usage >= prev.usageUsec {
+					if elapsed := now.Sub(prev.at).Microseconds(); elapsed > 0 {
+						group.CPUPercent = float64(usage-prev.usageUsec) / float64(elapsed) * 100
+					}
+				}
+				next[cgroupPath] = cgroupUsage{usageUsec: usage, at: now}
+			}
+		}
+
+		group.CPUHostPercent = group.CPUPercent / c.numCPU
+		sort.Slice(group.PIDs, func(i, j int) bool { return group.PIDs[i] < group.PIDs[j] })
+		result = append(result, *group)
+	}
+
+	// Группы без процессов в этом снимке вытесняются вместе с точкой отсчёта CPU.
+	c.prev = next
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].CPUPercent != result[j].CPUPercent {
+			return result[i].CPUPercent > result[j].CPUPercent
+		}
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
+
+// MatchContainer сообщает, подходит ли процесс под фильтр контейнера: полный ID или его
+// префикс (как в docker ps), "none" - процессы вне контейнеров. Пустой фильтр подходит всем.
+func MatchContainer(containerID, filter string) bool {
+	switch filter {
+	case "":
+		return true
+	case "none":
+		return containerID == ""
+	default:
+		return containerID != "" && strings.HasPrefix(containerID, filter)
+	}
+}
@@ -0,0 +1,274 @@
+package getmetrics
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+// DefaultConnectionsSummaryLimit - число строк в ByRemoteHost и ByProcess сводки по умолчанию.
+const DefaultConnectionsSummaryLimit = 20
+
+// connectionStatePattern - допустимый вид состояния в фильтре (ESTABLISHED, CLOSE_WAIT, NONE).
+var connectionStatePattern = regexp.MustCompile(`^[A-Z0-9_]+$`)
+
+// ConnectionFilter ограничивает список соединений. Нулевые значения полей - без ограничения.
+type ConnectionFilter struct {
+	PID        int32
+	Protocols  []string
+	States     []string
+	LocalPort  uint32
+	RemotePort uint32
+	// Remote - удалённый адрес или подсеть; для одиночного адреса длина префикса равна его разрядности.
+	Remote netip.Prefix
+}
+
+// ParseConnectionFilter разбирает фильтр соединений из query-параметров:
+// pid, protocol (tcp, tcp6, udp, udp6), state (ESTABLISHED, CLOSE_WAIT, ...; без учёта регистра),
+// localPort, remotePort и remote (IP-адрес или подсеть CIDR). Несколько протоколов и состояний - через запятую.
+func ParseConnectionFilter(query url.Values) (ConnectionFilter, error) {
+	var filter ConnectionFilter
+
+	if value := query.Get("pid"); value != "" {
+		pid, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || pid <= 0 {
+			return filter, fmt.Errorf("некорректное значение параметра pid: %q", value)
+		}
+		filter.PID = int32(pid)
+	}
+
+	protocols, err := ParseListParam(query, "protocol", []string{"tcp", "tcp6", "udp", "udp6"})
+	if err != nil {
+		return filter, err
+	}
+	filter.Protocols = protocols
+
+	states, err := ParseListParam(query, "state", nil)
+	if err != nil {
+		return filter, err
+	}
+	for _, value := range states {
+		value = strings.ToUpper(value)
+		if !connectionStatePattern.MatchString(value) {
+			return filter, fmt.Errorf("некорректное значение параметра state: %q", value)
+		}
+		filter.States = append(filter.States, value)
+	}
+
+	for name, port := range map[string]*uint32{"localPort": &filter.LocalPort, "remotePort": &filter.RemotePort} {
+		if value := query.Get(name); value != "" {
+			n, err := strconv.ParseUint(value, 10, 16)
+			if err != nil || n == 0 {
+				return filter, fmt.Errorf("некорректное значение параметра %s: %q", name, value)
+			}
+			*port = uint32(n)
+		}
+	}
+
+	if value := query.Get("remote"); value != "" {
+		remote, err := parseAddrOrPrefix(value)
+		if err != nil {
+			return filter, fmt.Errorf("некорректное значение параметра remote: %q, ожидается IP-адрес или подсеть CIDR", value)
+		}
+		filter.Remote = remote
+	}
+
+	return filter, nil
+}
+
+// Match сообщает, подходит ли соединение под фильтр. "tcp" и "udp" включают IPv6.
+func (f ConnectionFilter) Match(conn models.Connection) bool {
+	if f.PID != 0 && conn.PID != f.PID {
+		return false
+	}
+	if !matchProtocol(conn.Protocol, f.Protocols) {
+		return false
+	}
+	if len(f.States) > 0 && !slices.Contains(f.States, conn.Status) {
+		return false
+	}
+	if f.LocalPort != 0 && conn.LocalPort != f.LocalPort {
+		return false
+	}
+	if f.RemotePort != 0 && conn.RemotePort != f.RemotePort {
+		return false
+	}
+	if f.Remote.IsValid() {
+		addr, err := netip.ParseAddr(conn.RemoteIP)
+		if err != nil || !f.Remote.Contains(addr.Unmap()) {
+			return false
+		}
+	}
+	return true
+}
+
+// CollectConnections снимает TCP- и UDP-сокеты всех процессов. Имена процессов берутся из names,
+// а отсутствующие там читаются из /proc и добавляются в names.
+func CollectConnections(names map[int32]string) ([]models.Connection, error) {
+	conns, err := net.Connections("inet")
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить сетевые соединения: %w", err)
+	}
+	return ConvertConnections(conns, names), nil
+}
+
+// ConvertConnections переводит сокеты gopsutil в модель API. UNIX-сокеты и сокеты без локального порта
+// (UDP до bind) пропускаются. Имена процессов берутся из names, а отсутствующие там читаются из /proc
+// и добавляются в names. Результат упорядочен по PID, локальному порту и удалённому адресу.
+func ConvertConnections(conns []net.ConnectionStat, names map[int32]string) []models.Connection {
+	result := make([]models.Connection, 0, len(conns))
+	for _, c := range conns {
+		protocol := connectionType(c.Family, c.Type)
+		if c.Family == afUnix || (protocol != "tcp" && protocol != "udp") || c.Laddr.Port == 0 {
+			continue
+		}
+
+		family := connectionFamily(c.Family)
+		if family == "ipv6" {
+			protocol += "6"
+		}
+
+		conn := models.Connection{
+			Protocol:  protocol,
+			Family:    family,
+			PID:       c.Pid,
+			Status:    c.Status,
+			LocalAddr: formatAddr(c.Family, c.Laddr.IP, c.Laddr.Port),
+			LocalIP:   c.Laddr.IP,
+			LocalPort: c.Laddr.Port,
+		}
+		if c.Raddr.Port != 0 {
+			conn.RemoteAddr = formatAddr(c.Family, c.Raddr.IP, c.Raddr.Port)
+			conn.RemoteIP = c.Raddr.IP
+			conn.RemotePort = c.Raddr.Port
+		}
+
+		if c.Pid > 0 {
+			name, ok := names[c.Pid]
+			if !ok {
+				name = listeningProcessName(c.Pid)
+				names[c.Pid] = name
+			}
+			conn.Process = name
+		}
+
+		result = append(result, conn)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.PID != b.PID {
+			return a.PID < b.PID
+		}
+		if a.LocalPort != b.LocalPort {
+			return a.LocalPort < b.LocalPort
+		}
+		return a.RemoteAddr < b.RemoteAddr
+	})
+	return result
+}
+
+// FilterConnections возвращает соединения, подходящие под фильтр.
+func FilterConnections(conns []models.Connection, filter ConnectionFilter) []models.Connection {
+	result := make([]models.Connection, 0, len(conns))
+	for _, conn := range conns {
+		if filter.Match(conn) {
+			result = append(result, conn)
+		}
+	}
+	return result
+}
+
+// SummarizeConnections считает соединения по состояниям, удалённым адресам и процессам.
+// В ByRemoteHost и ByProcess остаются limit строк с наибольшим числом соединений.
+func SummarizeConnections(conns []models.Connection, limit int) models.ConnectionsSummary {
+	summary := models.ConnectionsSummary{
+		Total:   len(conns),
+		ByState: make(map[string]int),
+	}
+
+	hosts := make(map[string]*models.ConnectionHostCount)
+	processes := make(map[int32]*models.ConnectionProcessCount)
+	for _, conn := range conns {
+		summary.ByState[conn.Status]++
+
+		if conn.RemoteIP != "" {
+			ip := conn.RemoteIP
+			// IPv4-адрес, пришедший через IPv6-сокет (::ffff:10.0.0.1), считается вместе с IPv4.
+			if addr, err := netip.ParseAddr(ip); err == nil {
+				ip = addr.Unmap().String()
+			}
+			host, ok := hosts[ip]
+			if !ok {
+				host = &models.ConnectionHostCount{RemoteIP: ip, States: make(map[string]int)}
+				hosts[ip] = host
+			}
+			host.Count++
+			host.States[conn.Status]++
+		}
+
+		proc, ok := processes[conn.PID]
+		if !ok {
+			proc = &models.ConnectionProcessCount{PID: conn.PID, Process: conn.Process, States: make(map[string]int)}
+			processes[conn.PID] = proc
+		}
+		proc.Count++
+		proc.States[conn.Status]++
+	}
+
+	summary.ByRemoteHost = make([]models.ConnectionHostCount, 0, len(hosts))
+	for _, host := range hosts {
+		summary.ByRemoteHost = append(summary.ByRemoteHost, *host)
+	}
+	sort.Slice(summary.ByRemoteHost, func(i, j int) bool {
+		a, b := summary.ByRemoteHost[i], summary.ByRemoteHost[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.RemoteIP < b.RemoteIP
+	})
+
+	summary.ByProcess = make([]models.ConnectionProcessCount, 0, len(processes))
+	for _, proc := range processes {
+		summary.ByProcess = append(summary.ByProcess, *proc)
+	}
+	sort.Slice(summary.ByProcess, func(i, j int) bool {
+		a, b := summary.ByProcess[i], summary.ByProcess[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.PID < b.PID
+	})
+
+	if limit > 0 {
+		summary.ByRemoteHost = summary.ByRemoteHost[:min(limit, len(summary.ByRemoteHost))]
+		summary.ByProcess = summary.ByProcess[:min(limit, len(summary.ByProcess))]
+	}
+	return summary
+}
+
+// parseAddrOrPrefix разбирает IP-адрес или подсеть CIDR. Адрес превращается в префикс полной длины.
+func parseAddrOrPrefix(value string) (netip.Prefix, error) {
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
@@ -0,0 +1,118 @@
+package getmetrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/shirou/gopsutil/v4/cpu"
+)
+
+// cpuPrimeInterval - интервал первого замера, когда предыдущих счётчиков ещё нет.
+const cpuPrimeInterval = 100 * time.Millisecond
+
+// CPUCollector считает загрузку CPU по приросту счётчиков cpu.Times между вызовами:
+// общую, по каждому ядру и с разбивкой на user/system/iowait/irq/steal/idle.
+type CPUCollector struct {
+	mu        sync.Mutex
+	prevTotal *cpu.TimesStat
+	prevCores []cpu.TimesStat
+}
+
+// NewCPUCollector создаёт сборщик загрузки CPU.
+func NewCPUCollector() *CPUCollector {
+	return &CPUCollector{}
+}
+
+// Collect возвращает загрузку CPU с предыдущего вызова. При первом вызове
+// счётчики снимаются дважды с интервалом cpuPrimeInterval.
+func (c *CPUCollector) Collect() (models.CPUStats, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.prevTotal == nil {
+		total, cores, err := readCPUTimes()
+		if err != nil {
+			return models.CPUStats{}, err
+		}
+		c.prevTotal, c.prevCores = &total, cores
+		time.Sleep(cpuPrimeInterval)
+	}
+
+	total, cores, err := readCPUTimes()
+	if err != nil {
+		return models.CPUStats{}, err
+	}
+
+	stats := models.CPUStats{
+		Breakdown: cpuBreakdown(*c.prevTotal, total),
+		Cores:     make([]float64, 0, len(cores)),
+	}
+	stats.Total = 100 - stats.Breakdown.Idle - stats.Breakdown.IOWait
+
+	// Если число ядер изменилось (hotplug), по ядрам отдаём нули до следующего замера.
+	for i, core := range cores {
+		if len(c.prevCores) != len(cores) {
+			stats.Cores = append(stats.Cores, 0)
+			continue
+		}
+		breakdown := cpuBreakdown(c.prevCores[i], core)
+		stats.Cores = append(stats.Cores, 100-breakdown.Idle-breakdown.IOWait)
+	}
+
+	c.prevTotal, c.prevCores = &total, cores
+	return stats, nil
+}
+
+// readCPUTimes читает суммарные счётчики времени CPU и счётчики каждого ядра.
+func readCPUTimes() (cpu.TimesStat, []cpu.TimesStat, error) {
+	total, err := cpu.Times(false)
+	if err != nil {
+		return cpu.TimesStat{}, nil, err
+	}
+	cores, err := cpu.Times(true)
+	if err != nil {
+		return cpu.TimesStat{}, nil, err
+	}
+	if len(total) == 0 {
+		return cpu.TimesStat{}, cores, nil
+	}
+	return total[0], cores, nil
+}
+
+// cpuBreakdown переводит прирост счётчиков между двумя замерами в проценты.
+// Если прироста нет или счётчики сбросились, всё время считается простоем.
+func cpuBreakdown(prev, cur cpu.TimesStat) models.CPUBreakdown {
+	delta := func(a, b float64) float64 {
+		if b < a {
+			return 0
+		}
+		return b - a
+	}
+
+	user := delta(prev.User, cur.User)
+	nice := delta(prev.Nice, cur.Nice)
+	system := delta(prev.System, cur.System)
+	idle := delta(prev.Idle, cur.Idle)
+	iowait := delta(prev.Iowait, cur.Iowait)
+	irq := delta(prev.Irq, cur.Irq)
+	softirq := delta(prev.Softirq, cur.Softirq)
+	steal := delta(prev.Steal, cur.Steal)
+
+	total := user + nice + system + idle + iowait + irq + softirq + steal
+	if total <= 0 {
+		return models.CPUBreakdown{Idle: 100}
+	}
+
+	percent := func(v float64) float64 { return v / total * 100 }
+	return models.CPUBreakdown{
+		User:    percent(user),
+		Nice:    percent(nice),
+		System:  percent(system),
+		Idle:    percent(idle),
+		IOWait:  percent(iowait),
+		IRQ:     percent(irq),
+		SoftIRQ: percent(softirq),
+		Steal:   percent(steal),
+	}
+}
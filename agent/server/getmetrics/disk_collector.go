@@ -0,0 +1,132 @@
+package getmetrics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/shirou/gopsutil/v4/disk"
+)
+
+// diskPrimeInterval - интервал первого замера ввода-вывода, когда предыдущих счётчиков ещё нет.
+const diskPrimeInterval = 500 * time.Millisecond
+
+// DiskCollector снимает заполненность точек монтирования и считает скорость
+// ввода-вывода устройств по приросту счётчиков между вызовами.
+type DiskCollector struct {
+	mu     sync.Mutex
+	prev   map[string]disk.IOCountersStat
+	prevAt time.Time
+}
+
+// NewDiskCollector создаёт сборщик метрик дисков.
+func NewDiskCollector() *DiskCollector {
+	return &DiskCollector{}
+}
+
+// Collect возвращает снимок дисков. Скорости считаются с предыдущего вызова;
+// при первом вызове счётчики снимаются дважды с интервалом diskPrimeInterval.
+func (c *DiskCollector) Collect() (models.DiskStats, error) {
+	mounts, err := diskUsage()
+	if err != nil {
+		return models.DiskStats{}, err
+	}
+
+	devices, err := c.collectIO()
+	if err != nil {
+		return models.DiskStats{}, err
+	}
+
+	return models.DiskStats{Mounts: mounts, Devices: devices}, nil
+}
+
+// collectIO считает скорости ввода-вывода устройств с предыдущего вызова.
+func (c *DiskCollector) collectIO() ([]models.DiskIO, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.prev == nil {
+		counters, err := disk.IOCounters()
+		if err != nil {
+			return nil, err
+		}
+		c.prev, c.prevAt = counters, time.Now()
+		time.Sleep(diskPrimeInterval)
+	}
+
+	counters, err := disk.IOCounters()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	elapsed := now.Sub(c.prevAt).Seconds()
+
+	devices := make([]models.DiskIO, 0, len(counters))
+	for name, cur := range counters {
+		if isVirtualBlockDevice(name) {
+			continue
+		}
+
+		io := models.DiskIO{Device: name}
+		if prev, ok := c.prev[name]; ok && elapsed > 0 {
+			io.ReadBytesPerSec = counterRate(prev.ReadBytes, cur.ReadBytes, elapsed)
+			io.WriteBytesPerSec = counterRate(prev.WriteBytes, cur.WriteBytes, elapsed)
+			io.ReadIOPS = counterRate(prev.ReadCount, cur.ReadCount, elapsed)
+			io.WriteIOPS = counterRate(prev.WriteCount, cur.WriteCount, elapsed)
+			// IoTime - миллисекунды, в течение которых у устройства были запросы в обработке.
+			io.UtilPercent = min(counterRate(prev.IoTime, cur.IoTime, elapsed)/1000*100, 100)
+		}
+		devices = append(devices, io)
+	}
+
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Device < devices[j].Device })
+
+	c.prev, c.prevAt = counters, now
+	return devices, nil
+}
+
+// diskUsage возвращает заполненность точек монтирования физических файловых систем.
+// Точки, для которых не удалось получить данные (нет прав, отключённый сетевой диск), пропускаются.
+func diskUsage() ([]models.DiskUsage, error) {
+	partitions, err := disk.Partitions(false)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(partitions))
+	mounts := make([]models.DiskUsage, 0, len(partitions))
+	for _, partition := range partitions {
+		if seen[partition.Mountpoint] {
+			continue
+		}
+		seen[partition.Mountpoint] = true
+
+		usage, err := disk.Usage(partition.Mountpoint)
+		if err != nil || usage.Total == 0 {
+			continue
+		}
+
+		mounts = append(mounts, models.DiskUsage{
+			Mountpoint:        partition.Mountpoint,
+			Device:            partition.Device,
+			Fstype:            partition.Fstype,
+			Total:             usage.Total,
+			Used:              usage.Used,
+			Free:              usage.Free,
+			UsedPercent:       usage.UsedPercent,
+			InodesTotal:       usage.InodesTotal,
+			InodesUsed:        usage.InodesUsed,
+			InodesUsedPercent: usage.InodesUsedPercent,
+		})
+	}
+
+	sort.Slice(mounts, func(i, j int) bool { return mounts[i].Mountpoint < mounts[j].Mountpoint })
+	return mounts, nil
+}
+
+// isVirtualBlockDevice отсекает loop- и ram-устройства, которые не отражают нагрузку на реальные диски.
+func isVirtualBlockDevice(name string) bool {
+	return strings.HasPrefix(name, "loop") || strings.HasPrefix(name, "ram")
+}
@@ -0,0 +1,135 @@
+package getmetrics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// maxExeHashSize - исполняемые файлы больше этого размера не хэшируются, чтобы не нагружать диск.
+const maxExeHashSize = 512 * 1024 * 1024
+
+// exeHashKey - признаки конкретной версии исполняемого файла: путь, устройство, inode и время
+// изменения. Замена файла по тому же пути или изменение на месте дают новый ключ.
+type exeHashKey struct {
+	path  string
+	dev   uint64
+	inode uint64
+	mtime int64
+}
+
+// exeHashEntry - закэшированный хэш исполняемого файла.
+type exeHashEntry struct {
+	hash       string
+	generation uint64
+}
+
+// ExeHasher считает SHA-256 исполняемых файлов процессов. Хэш файла считается один раз на
+// (путь, устройство, inode, mtime): пока файл не заменён и не изменён, повторные вызовы берут хэш из кэша.
+type ExeHasher struct {
+	mu         sync.Mutex
+	cache      map[exeHashKey]*exeHashEntry
+	generation uint64
+}
+
+// NewExeHasher создаёт хэшер исполняемых файлов с пустым кэшем.
+func NewExeHasher() *ExeHasher {
+	return &ExeHasher{cache: make(map[exeHashKey]*exeHashEntry)}
+}
+
+// Cached возвращает хэш исполняемого файла процесса, только если он уже посчитан для текущей
+// версии файла. Файл не читается, поэтому метод можно вызывать для каждого снимка процессов.
+func (h *ExeHasher) Cached(pid int32, exe string) (string, bool) {
+	if exe == "" {
+		return "", false
+	}
+	info, err := os.Stat(exeFile(pid, exe))
+	if err != nil {
+		return "", false
+	}
+	key := newExeHashKey(exe, info)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	entry, ok := h.cache[key]
+	if !ok {
+		return "", false
+	}
+	entry.generation = h.generation
+	return entry.hash, true
+}
+
+// Hash возвращает SHA-256 (hex) исполняемого файла процесса. В Linux файл читается через
+// /proc/<pid>/exe, поэтому хэш считается и для удалённых файлов, и для процессов в контейнерах.
+// Файл читается без блокировки кэша: долгий хэш не задерживает Cached.
+func (h *ExeHasher) Hash(pid int32, exe string) (string, error) {
+	if exe == "" {
+		return "", fmt.Errorf("путь к исполняемому файлу PID=%d неизвестен", pid)
+	}
+
+	file := exeFile(pid, exe)
+	info, err := os.Stat(file)
+	if err != nil {
+		return "", fmt.Errorf("не удалось прочитать исполняемый файл PID=%d: %w", pid, err)
+	}
+	key := newExeHashKey(exe, info)
+
+	h.mu.Lock()
+	if entry, ok := h.cache[key]; ok {
+		entry.generation = h.generation
+		h.mu.Unlock()
+		return entry.hash, nil
+	}
+	h.mu.Unlock()
+
+	if info.Size() > maxExeHashSize {
+		return "", fmt.Errorf("исполняемый файл %s больше %d МБ, хэш не считается", exe, maxExeHashSize/1024/1024)
+	}
+	hash, err := hashFile(file)
+	if err != nil {
+		return "", fmt.Errorf("не удалось посчитать хэш %s: %w", exe, err)
+	}
+
+	h.mu.Lock()
+	h.cache[key] = &exeHashEntry{hash: hash, generation: h.generation}
+	h.mu.Unlock()
+	return hash, nil
+}
+
+// Sweep удаляет из кэша файлы, которые не запрашивались с предыдущего вызова Sweep.
+// Вызывается после обработки каждого снимка процессов, чтобы кэш не рос бесконечно.
+func (h *ExeHasher) Sweep() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for key, entry := range h.cache {
+		if entry.generation != h.generation {
+			delete(h.cache, key)
+		}
+	}
+	h.generation++
+}
+
+// newExeHashKey собирает ключ кэша по пути и результату stat исполняемого файла.
+func newExeHashKey(exe string, info os.FileInfo) exeHashKey {
+	dev, inode := fileID(info)
+	return exeHashKey{path: exe, dev: dev, inode: inode, mtime: info.ModTime().UnixNano()}
+}
+
+// hashFile считает SHA-256 содержимого файла.
+func hashFile(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	sum := sha256.New()
+	if _, err := io.Copy(sum, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
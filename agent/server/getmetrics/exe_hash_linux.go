@@ -0,0 +1,21 @@
+package getmetrics
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// exeFile возвращает файл, через который читается исполняемый файл процесса. Ссылка /proc/<pid>/exe
+// открывает тот файл, из которого процесс запущен, даже если путь удалён или виден только внутри контейнера.
+func exeFile(pid int32, _ string) string {
+	return "/proc/" + strconv.Itoa(int(pid)) + "/exe"
+}
+
+// fileID возвращает устройство и номер inode файла: вместе они однозначно задают файл в системе.
+func fileID(info os.FileInfo) (uint64, uint64) {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(stat.Dev), stat.Ino
+	}
+	return 0, 0
+}
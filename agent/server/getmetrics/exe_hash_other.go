@@ -0,0 +1,15 @@
+//go:build !linux
+
+package getmetrics
+
+import "os"
+
+// exeFile возвращает путь к исполняемому файлу процесса: вне Linux нет /proc/<pid>/exe.
+func exeFile(_ int32, exe string) string {
+	return exe
+}
+
+// fileID вне Linux не используется: замена файла определяется по времени изменения.
+func fileID(_ os.FileInfo) (uint64, uint64) {
+	return 0, 0
+}
@@ -3,75 +3,205 @@ package getmetrics
 import (
 	"fmt"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strings"
 
 	"github.com/RZhurakovskiy/agent/server/models"
 	"github.com/shirou/gopsutil/v4/net"
 	"github.com/shirou/gopsutil/v4/process"
 )
 
-func GetListeningPorts() ([]models.ListeningPort, error) {
-	conns, err := net.Connections("tcp")
-	if err != nil {
-		return nil, fmt.Errorf("не удалось получить TCP-соединения: %w", err)
-	}
+// Нормализованные состояния сокетов в ответе /api/listening-ports.
+const (
+	SocketStateListen      = "listen"      // Принимает соединения (TCP, UNIX stream) или датаграммы без фиксированного получателя (UDP, UNIX dgram)
+	SocketStateEstablished = "established" // Связан с удалённой стороной
+	SocketStateOther       = "other"       // Переходные состояния TCP и сокеты с неизвестным состоянием
+)
 
-	var result []models.ListeningPort
+var (
+	// ListeningPortProtocols - допустимые значения фильтра по протоколу. "tcp" и "udp" включают и IPv6.
+	ListeningPortProtocols = []string{"tcp", "tcp6", "udp", "udp6", "unix"}
+	// SocketStates - допустимые значения фильтра по состоянию.
+	SocketStates = []string{SocketStateListen, SocketStateEstablished, SocketStateOther}
+)
 
-	for _, conn := range conns {
-		if conn.Laddr.Port == 0 || conn.Pid <= 0 {
-			continue
+// ListeningPortsFilter ограничивает список сокетов. Пустой список значений - без ограничения.
+type ListeningPortsFilter struct {
+	Protocols []string
+	States    []string
+}
+
+// GetListeningPorts возвращает TCP- и UDP-сокеты (IPv4 и IPv6) и именованные UNIX-сокеты процессов.
+// Сокеты без порта (UDP до bind), без пути (анонимные UNIX-сокеты) или без известного процесса пропускаются.
+// Сначала идут сокеты в состоянии listen, внутри состояния - по порту, UNIX-сокеты - после IP.
+func GetListeningPorts(filter ListeningPortsFilter) ([]models.ListeningPort, error) {
+	var sockets []models.ListeningPort
+
+	if kind := inetConnectionsKind(filter.Protocols); kind != "" {
+		conns, err := net.Connections(kind)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось получить IP-сокеты (%s): %w", kind, err)
+		}
+		for _, conn := range conns {
+			if port, ok := convertInetSocket(conn); ok {
+				sockets = append(sockets, port)
+			}
 		}
+	}
 
-		proto := ""
-		switch conn.Family {
-		case 2:
-			proto = "tcp"
-		case 10:
-			proto = "tcp6"
-		default:
-			continue
+	if len(filter.Protocols) == 0 || slices.Contains(filter.Protocols, "unix") {
+		unixSockets, err := listUnixSockets()
+		if err != nil {
+			return nil, fmt.Errorf("не удалось получить UNIX-сокеты: %w", err)
 		}
+		sockets = append(sockets, unixSockets...)
+	}
 
-		localAddr := fmt.Sprintf("%s:%d", conn.Laddr.IP, conn.Laddr.Port)
+	result := []models.ListeningPort{}
+	processNames := make(map[int32]string)
 
-		remoteAddr := "0.0.0.0:0"
-		if conn.Raddr.IP != "" && conn.Raddr.Port != 0 {
-			remoteAddr = fmt.Sprintf("%s:%d", conn.Raddr.IP, conn.Raddr.Port)
-		} else if conn.Status == "LISTEN" {
-			remoteAddr = "-"
+	for _, port := range sockets {
+		if port.PID <= 0 || !matchProtocol(port.Protocol, filter.Protocols) {
+			continue
+		}
+		if len(filter.States) > 0 && !slices.Contains(filter.States, port.State) {
+			continue
 		}
 
-		processName := "неизвестно"
-		p, err := process.NewProcess(conn.Pid)
-		if err == nil {
-			if exe, err := p.Exe(); err == nil && exe != "" {
-				processName = filepath.Base(exe)
-			} else if name, err := p.Name(); err == nil && name != "" {
-				processName = name
-			}
+		name, ok := processNames[port.PID]
+		if !ok {
+			name = listeningProcessName(port.PID)
+			processNames[port.PID] = name
 		}
+		port.Process = name
 
-		result = append(result, models.ListeningPort{
-			Port:       conn.Laddr.Port,
-			Protocol:   proto,
-			PID:        conn.Pid,
-			Process:    processName,
-			Status:     conn.Status,
-			LocalAddr:  localAddr,
-			RemoteAddr: remoteAddr,
-		})
+		result = append(result, port)
 	}
 
 	sort.Slice(result, func(i, j int) bool {
-		if result[i].Status == "LISTEN" && result[j].Status != "LISTEN" {
-			return true
+		a, b := result[i], result[j]
+		if (a.State == SocketStateListen) != (b.State == SocketStateListen) {
+			return a.State == SocketStateListen
+		}
+		if (a.Protocol == "unix") != (b.Protocol == "unix") {
+			return b.Protocol == "unix"
 		}
-		if result[i].Status != "LISTEN" && result[j].Status == "LISTEN" {
-			return false
+		if a.Port != b.Port {
+			return a.Port < b.Port
 		}
-		return result[i].Port < result[j].Port
+		if a.Protocol != b.Protocol {
+			return a.Protocol < b.Protocol
+		}
+		if a.LocalAddr != b.LocalAddr {
+			return a.LocalAddr < b.LocalAddr
+		}
+		return a.PID < b.PID
 	})
 
 	return result, nil
 }
+
+// inetConnectionsKind выбирает самый узкий набор IP-сокетов gopsutil, покрывающий фильтр по протоколу:
+// каждый вызов net.Connections обходит дескрипторы всех процессов. Пустая строка - IP-сокеты не нужны.
+func inetConnectionsKind(protocols []string) string {
+	if len(protocols) == 0 {
+		return "inet"
+	}
+
+	var tcp, udp bool
+	for _, protocol := range protocols {
+		switch protocol {
+		case "tcp", "tcp6":
+			tcp = true
+		case "udp", "udp6":
+			udp = true
+		}
+	}
+
+	switch {
+	case tcp && udp:
+		return "inet"
+	case tcp:
+		return "tcp"
+	case udp:
+		return "udp"
+	default:
+		return ""
+	}
+}
+
+// matchProtocol сообщает, подходит ли протокол сокета под фильтр: "tcp" включает tcp6, "udp" - udp6.
+func matchProtocol(protocol string, filter []string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	return slices.Contains(filter, protocol) || slices.Contains(filter, strings.TrimSuffix(protocol, "6"))
+}
+
+// convertInetSocket переводит TCP- или UDP-сокет gopsutil в модель API и определяет его нормализованное состояние.
+func convertInetSocket(conn net.ConnectionStat) (models.ListeningPort, bool) {
+	port := models.ListeningPort{
+		Port:       conn.Laddr.Port,
+		Protocol:   connectionType(conn.Family, conn.Type),
+		Family:     connectionFamily(conn.Family),
+		PID:        conn.Pid,
+		Status:     conn.Status,
+		LocalAddr:  formatAddr(conn.Family, conn.Laddr.IP, conn.Laddr.Port),
+		RemoteAddr: "-",
+	}
+	if conn.Laddr.Port == 0 || (port.Protocol != "tcp" && port.Protocol != "udp") {
+		return port, false
+	}
+	if port.Family == "ipv6" {
+		port.Protocol += "6"
+	}
+	if conn.Raddr.Port != 0 {
+		port.RemoteAddr = formatAddr(conn.Family, conn.Raddr.IP, conn.Raddr.Port)
+	}
+
+	switch {
+	case conn.Type == sockDgram && conn.Raddr.Port != 0:
+		// UDP-сокет после connect() получает датаграммы только от одного адреса.
+		port.State = SocketStateEstablished
+	case conn.Type == sockDgram:
+		port.State = SocketStateListen
+	case conn.Status == "LISTEN":
+		port.State = SocketStateListen
+	case conn.Status == "ESTABLISHED":
+		port.State = SocketStateEstablished
+	default:
+		port.State = SocketStateOther
+	}
+	return port, true
+}
+
+// newUnixSocketPort заполняет модель API для именованного UNIX-сокета процесса.
+func newUnixSocketPort(pid int32, path string, sockType uint32, status, state string) models.ListeningPort {
+	return models.ListeningPort{
+		Protocol:   "unix",
+		Family:     connectionFamily(afUnix),
+		PID:        pid,
+		Status:     status,
+		State:      state,
+		LocalAddr:  path,
+		RemoteAddr: "-",
+		Path:       path,
+		SocketType: connectionType(afUnix, sockType),
+	}
+}
+
+// listeningProcessName возвращает имя исполняемого файла процесса, а если путь недоступен - имя процесса.
+func listeningProcessName(pid int32) string {
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return "неизвестно"
+	}
+	if exe, err := p.Exe(); err == nil && exe != "" {
+		return filepath.Base(exe)
+	}
+	if name, err := p.Name(); err == nil && name != "" {
+		return name
+	}
+	return "неизвестно"
+}
@@ -0,0 +1,112 @@
+package getmetrics
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/shirou/gopsutil/v4/mem"
+)
+
+// vmstatPath - счётчики виртуальной памяти ядра Linux. На других системах файла нет,
+// и счётчик OOM kill остаётся нулевым.
+const vmstatPath = "/proc/vmstat"
+
+// MemoryCollector снимает расширенное состояние памяти и считает скорость подкачки
+// и число OOM kill по приросту счётчиков между вызовами.
+type MemoryCollector struct {
+	mu       sync.Mutex
+	prevAt   time.Time
+	prevSin  uint64
+	prevSout uint64
+	prevOOM  uint64
+}
+
+// NewMemoryCollector создаёт сборщик метрик памяти.
+func NewMemoryCollector() *MemoryCollector {
+	return &MemoryCollector{}
+}
+
+// Collect возвращает состояние памяти. При первом вызове скорости подкачки и OOMKillsDelta нулевые.
+func (c *MemoryCollector) Collect() (models.MemoryStats, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	vm, err := mem.VirtualMemory()
+	if err != nil {
+		return models.MemoryStats{}, err
+	}
+
+	stats := models.MemoryStats{
+		UsedPercent: vm.UsedPercent,
+		Total:       vm.Total,
+		Used:        vm.Used,
+		Available:   vm.Available,
+		Cached:      vm.Cached,
+		Buffers:     vm.Buffers,
+		Shared:      vm.Shared,
+		Slab:        vm.Slab,
+		Dirty:       vm.Dirty,
+	}
+
+	now := time.Now()
+	elapsed := now.Sub(c.prevAt).Seconds()
+	first := c.prevAt.IsZero()
+
+	if swap, err := mem.SwapMemory(); err == nil {
+		stats.SwapTotal = swap.Total
+		stats.SwapUsed = swap.Used
+		stats.SwapUsedPercent = swap.UsedPercent
+
+		if !first && elapsed > 0 {
+			stats.SwapInRate = counterRate(c.prevSin, swap.Sin, elapsed)
+			stats.SwapOutRate = counterRate(c.prevSout, swap.Sout, elapsed)
+		}
+		c.prevSin, c.prevSout = swap.Sin, swap.Sout
+	}
+
+	if oomKills, err := readOOMKills(); err == nil {
+		stats.OOMKills = oomKills
+		if !first && oomKills >= c.prevOOM {
+			stats.OOMKillsDelta = oomKills - c.prevOOM
+		}
+		c.prevOOM = oomKills
+	}
+
+	c.prevAt = now
+	return stats, nil
+}
+
+// counterRate возвращает скорость роста счётчика в секунду. При сбросе счётчика возвращает 0.
+func counterRate(prev, cur uint64, elapsed float64) float64 {
+	if cur < prev {
+		return 0
+	}
+	return float64(cur-prev) / elapsed
+}
+
+// readOOMKills читает из /proc/vmstat число процессов, завершённых OOM killer с загрузки.
+func readOOMKills() (uint64, error) {
+	data, err := os.ReadFile(vmstatPath)
+	if err != nil {
+		return 0, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		name, value, ok := bytes.Cut(scanner.Bytes(), []byte(" "))
+		if !ok || string(name) != "oom_kill" {
+			continue
+		}
+		return strconv.ParseUint(string(bytes.TrimSpace(value)), 10, 64)
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("в %s нет счётчика oom_kill", vmstatPath)
+}
@@ -0,0 +1,84 @@
+package getmetrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+// networkPrimeInterval - интервал первого замера трафика, когда предыдущих счётчиков ещё нет.
+const networkPrimeInterval = 500 * time.Millisecond
+
+// NetworkCollector считает трафик сетевых интерфейсов по приросту счётчиков между вызовами.
+type NetworkCollector struct {
+	mu     sync.Mutex
+	prev   map[string]net.IOCountersStat
+	prevAt time.Time
+}
+
+// NewNetworkCollector создаёт сборщик метрик сетевых интерфейсов.
+func NewNetworkCollector() *NetworkCollector {
+	return &NetworkCollector{}
+}
+
+// Collect возвращает трафик интерфейсов с предыдущего вызова. При первом вызове
+// счётчики снимаются дважды с интервалом networkPrimeInterval. Интерфейс, появившийся
+// между вызовами, получает нулевые скорости до следующего замера.
+func (c *NetworkCollector) Collect() (models.NetworkStats, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.prev == nil {
+		counters, err := readNetworkCounters()
+		if err != nil {
+			return models.NetworkStats{}, err
+		}
+		c.prev, c.prevAt = counters, time.Now()
+		time.Sleep(networkPrimeInterval)
+	}
+
+	counters, err := readNetworkCounters()
+	if err != nil {
+		return models.NetworkStats{}, err
+	}
+	now := time.Now()
+	elapsed := now.Sub(c.prevAt).Seconds()
+
+	interfaces := make([]models.NetworkInterface, 0, len(counters))
+	for name, cur := range counters {
+		iface := models.NetworkInterface{Name: name}
+		if prev, ok := c.prev[name]; ok && elapsed > 0 {
+			iface.RxBytesPerSec = counterRate(prev.BytesRecv, cur.BytesRecv, elapsed)
+			iface.TxBytesPerSec = counterRate(prev.BytesSent, cur.BytesSent, elapsed)
+			iface.RxPacketsPerSec = counterRate(prev.PacketsRecv, cur.PacketsRecv, elapsed)
+			iface.TxPacketsPerSec = counterRate(prev.PacketsSent, cur.PacketsSent, elapsed)
+			iface.RxErrorsPerSec = counterRate(prev.Errin, cur.Errin, elapsed)
+			iface.TxErrorsPerSec = counterRate(prev.Errout, cur.Errout, elapsed)
+			iface.RxDroppedPerSec = counterRate(prev.Dropin, cur.Dropin, elapsed)
+			iface.TxDroppedPerSec = counterRate(prev.Dropout, cur.Dropout, elapsed)
+		}
+		interfaces = append(interfaces, iface)
+	}
+
+	sort.Slice(interfaces, func(i, j int) bool { return interfaces[i].Name < interfaces[j].Name })
+
+	c.prev, c.prevAt = counters, now
+	return models.NetworkStats{Interfaces: interfaces}, nil
+}
+
+// readNetworkCounters читает счётчики всех интерфейсов, индексированные по имени.
+func readNetworkCounters() (map[string]net.IOCountersStat, error) {
+	stats, err := net.IOCounters(true)
+	if err != nil {
+		return nil, err
+	}
+
+	counters := make(map[string]net.IOCountersStat, len(stats))
+	for _, stat := range stats {
+		counters[stat.Name] = stat
+	}
+	return counters, nil
+}
@@ -0,0 +1,135 @@
+package getmetrics
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/shirou/gopsutil/v4/host"
+	"github.com/shirou/gopsutil/v4/load"
+)
+
+// psiDir - каталог Pressure Stall Information ядра Linux (доступен с ядра 4.20).
+const psiDir = "/proc/pressure"
+
+// SystemPressure возвращает среднюю загрузку, время работы, число активных и заблокированных
+// задач и PSI. Недоступные на текущей системе показатели остаются нулевыми.
+func SystemPressure() (models.SystemPressure, error) {
+	avg, err := load.Avg()
+	if err != nil {
+		return models.SystemPressure{}, err
+	}
+
+	pressure := models.SystemPressure{
+		Load1:  avg.Load1,
+		Load5:  avg.Load5,
+		Load15: avg.Load15,
+	}
+
+	if bootTime, err := host.BootTime(); err == nil {
+		pressure.BootTime = time.Unix(int64(bootTime), 0).Format("2006-01-02 15:04:05")
+		if now := uint64(time.Now().Unix()); now > bootTime {
+			pressure.UptimeSec = now - bootTime
+		}
+	}
+
+	if misc, err := load.Misc(); err == nil {
+		pressure.ProcsRunning = misc.ProcsRunning
+		pressure.ProcsBlocked = misc.ProcsBlocked
+	}
+
+	psi := models.PSIStats{}
+	for _, resource := range []struct {
+		name   string
+		target **models.PSIResource
+	}{
+		{"cpu", &psi.CPU},
+		{"memory", &psi.Memory},
+		{"io", &psi.IO},
+	} {
+		if stat, err := readPSI(filepath.Join(psiDir, resource.name)); err == nil {
+			*resource.target = stat
+		}
+	}
+	if psi.CPU != nil || psi.Memory != nil || psi.IO != nil {
+		pressure.PSI = &psi
+	}
+
+	return pressure, nil
+}
+
+// readPSI разбирает файл /proc/pressure/<ресурс> вида
+// "some avg10=0.12 avg60=0.05 avg300=0.01 total=123".
+func readPSI(path string) (*models.PSIResource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	resource := &models.PSIResource{}
+	hasSome := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		values, err := parsePSIValues(fields[1:])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		switch fields[0] {
+		case "some":
+			resource.Some = values
+			hasSome = true
+		case "full":
+			resource.Full = &values
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !hasSome {
+		return nil, fmt.Errorf("%s: нет строки some", path)
+	}
+	return resource, nil
+}
+
+// parsePSIValues разбирает поля avg10=, avg60= и avg300=; остальные поля пропускаются.
+func parsePSIValues(fields []string) (models.PSIValues, error) {
+	var values models.PSIValues
+	for _, field := range fields {
+		name, raw, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+
+		var target *float64
+		switch name {
+		case "avg10":
+			target = &values.Avg10
+		case "avg60":
+			target = &values.Avg60
+		case "avg300":
+			target = &values.Avg300
+		default:
+			continue
+		}
+
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return models.PSIValues{}, fmt.Errorf("некорректное значение %s: %w", field, err)
+		}
+		*target = value
+	}
+	return values, nil
+}
@@ -0,0 +1,285 @@
+package getmetrics
+
+import (
+	"errors"
+	"io/fs"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/mem"
+	"github.com/shirou/gopsutil/v4/net"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// staticRefreshInterval - как часто перечитываются неизменяемые поля процесса.
+// Для пары PID + время создания они не меняются, кроме exec() в том же процессе
+// и переноса процесса в другую cgroup.
+const staticRefreshInterval = time.Minute
+
+// processEntry - состояние процесса между циклами: неизменяемые поля и CPU-время прошлого цикла.
+type processEntry struct {
+	createTime   int64
+	name         string
+	exe          string
+	cmdline      string
+	username     string
+	cgroup       string
+	containerID  string
+	systemdUnit  string
+	staticReadAt time.Time
+	cpuTime      float64
+	hasCPUTime   bool
+}
+
+// ProcessCollector собирает список процессов инкрементально. Неизменяемые поля
+// (имя, exe, cmdline, пользователь, cgroup) читаются один раз для пары PID + время
+// создания и кэшируются; каждый цикл обновляются только родитель, CPU, память, статус и порты.
+// Родитель не кэшируется: после завершения родителя ядро переназначает процесс init или subreaper.
+// Записи завершившихся процессов удаляются в том же цикле.
+//
+// CPU% считается по приросту CPU-времени за реальный интервал между циклами.
+// Процесс, который уже работал до первого цикла, в первом цикле получает 0%:
+// для него ещё нет точки отсчёта. Процесс, запущенный между циклами, получает
+// среднее с момента запуска - для него это и есть загрузка за интервал.
+type ProcessCollector struct {
+	mu      sync.Mutex
+	numCPU  float64
+	entries map[int32]*processEntry
+	lastRun time.Time
+}
+
+// NewProcessCollector создаёт сборщик процессов с пустым кэшем.
+func NewProcessCollector() *ProcessCollector {
+	numCPU := runtime.NumCPU()
+	if counts, err := cpu.Counts(true); err == nil && counts > 0 {
+		numCPU = counts
+	}
+
+	return &ProcessCollector{
+		numCPU:  float64(numCPU),
+		entries: make(map[int32]*processEntry),
+	}
+}
+
+// SampleProcesses снимает процессы новым сборщиком дважды с интервалом interval, чтобы CPU% считался
+// за этот интервал. Используется для запросов, которым не из чего взять предыдущий снимок.
+func SampleProcesses(allConnections []net.ConnectionStat, interval time.Duration) ([]models.ProcessInfo, error) {
+	collector := NewProcessCollector()
+	if _, err := collector.Collect([]net.ConnectionStat{}); err != nil {
+		return nil, err
+	}
+	time.Sleep(interval)
+	return collector.Collect(allConnections)
+}
+
+// Collect снимает список процессов. Вызовы сериализуются: интервал для расчёта CPU%
+// отсчитывается от предыдущего вызова этого же сборщика.
+func (c *ProcessCollector) Collect(allConnections []net.ConnectionStat) ([]models.ProcessInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pids, err := process.Pids()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	connectionsByPID := groupConnectionsByPID(allConnections)
+
+	var totalMemory uint64
+	if vm, err := mem.VirtualMemory(); err == nil {
+		totalMemory = vm.Total
+	}
+
+	// Каждый воркер пишет только в свой слот, поэтому слоты не требуют блокировки,
+	// а c.entries во время работы воркеров только читается.
+	infos := make([]models.ProcessInfo, len(pids))
+	entries := make([]*processEntry, len(pids))
+
+	const maxWorkers = 10
+	semaphore := make(chan struct{}, maxWorkers)
+	var wg sync.WaitGroup
+
+	for i, pid := range pids {
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func(i int, pid int32) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			info, entry, ok := c.collectOne(pid, c.entries[pid], now, totalMemory)
+			if !ok {
+				return
+			}
+			info.Ports = portsOf(connectionsByPID[pid])
+			infos[i] = info
+			entries[i] = entry
+		}(i, pid)
+	}
+
+	wg.Wait()
+
+	result := make([]models.ProcessInfo, 0, len(pids))
+	nextEntries := make(map[int32]*processEntry, len(pids))
+	for i, entry := range entries {
+		if entry == nil {
+			continue
+		}
+		result = append(result, infos[i])
+		nextEntries[pids[i]] = entry
+	}
+
+	// Завершившиеся процессы не попадают в новую карту и тем самым вытесняются из кэша.
+	c.entries = nextEntries
+	c.lastRun = now
+
+	return result, nil
+}
+
+// collectOne читает один процесс. prev - запись прошлого цикла с тем же PID (может быть nil
+// или принадлежать другому процессу, если PID переиспользован). ok=false - процесс уже завершился.
+func (c *ProcessCollector) collectOne(pid int32, prev *processEntry, now time.Time, totalMemory uint64) (models.ProcessInfo, *processEntry, bool) {
+	proc := &process.Process{Pid: pid}
+
+	createTime, err := proc.CreateTime()
+	if isProcessGone(err) {
+		return models.ProcessInfo{}, nil, false
+	}
+
+	entry := prev
+	if entry == nil || entry.createTime != createTime {
+		entry = &processEntry{createTime: createTime}
+	}
+	if now.Sub(entry.staticReadAt) >= staticRefreshInterval {
+		readStaticFields(proc, entry, now)
+	}
+
+	info := models.ProcessInfo{
+		PID:         pid,
+		Name:        entry.name,
+		Exe:         entry.exe,
+		Cmdline:     entry.cmdline,
+		Username:    entry.username,
+		CreateTime:  entry.createTime,
+		Cgroup:      entry.cgroup,
+		ContainerID: entry.containerID,
+		SystemdUnit: entry.systemdUnit,
+	}
+
+	if ppid, err := proc.Ppid(); err == nil {
+		info.ParentPID = ppid
+	}
+
+	if status, err := proc.Status(); err == nil && len(status) > 0 {
+		info.Status = status[0]
+	} else if isProcessGone(err) {
+		return models.ProcessInfo{}, nil, false
+	}
+
+	if times, err := proc.Times(); err == nil {
+		cpuTime := times.User + times.System
+		info.CPUPercent = c.cpuPercent(entry, cpuTime, now)
+		info.CPUHostPercent = info.CPUPercent / c.numCPU
+		entry.cpuTime, entry.hasCPUTime = cpuTime, true
+	} else {
+		entry.hasCPUTime = false
+	}
+
+	if memInfo, err := proc.MemoryInfo(); err == nil {
+		info.MemoryRSS = memInfo.RSS
+		info.MemoryVMS = memInfo.VMS
+		if totalMemory > 0 {
+			info.MemoryPercent = float64(memInfo.RSS) / float64(totalMemory) * 100
+		}
+	}
+
+	return info, entry, true
+}
+
+// readStaticFields читает поля, которые не меняются за время жизни процесса.
+func readStaticFields(proc *process.Process, entry *processEntry, now time.Time) {
+	entry.name = "неизвестно"
+	if name, err := proc.Name(); err == nil {
+		entry.name = name
+	}
+
+	if exe, err := proc.Exe(); err == nil {
+		entry.exe = exe
+	}
+
+	if cmdline, err := proc.Cmdline(); err == nil {
+		entry.cmdline = cmdline
+	}
+
+	if username, err := proc.Username(); err == nil {
+		entry.username = username
+	}
+
+	if cgroup, err := readProcessCgroup(proc.Pid); err == nil {
+		entry.cgroup = cgroup
+		entry.containerID, entry.systemdUnit = describeCgroup(cgroup)
+	}
+
+	entry.staticReadAt = now
+}
+
+// cpuPercent считает загрузку CPU процессом (100% = одно ядро) с предыдущего цикла.
+func (c *ProcessCollector) cpuPercent(entry *processEntry, cpuTime float64, now time.Time) float64 {
+	if entry.hasCPUTime {
+		elapsed := now.Sub(c.lastRun).Seconds()
+		if elapsed <= 0 || cpuTime < entry.cpuTime {
+			return 0
+		}
+		return (cpuTime - entry.cpuTime) / elapsed * 100
+	}
+
+	createdAt := time.UnixMilli(entry.createTime)
+	if entry.createTime <= 0 || c.lastRun.IsZero() || createdAt.Before(c.lastRun) {
+		return 0
+	}
+
+	elapsed := now.Sub(createdAt).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return cpuTime / elapsed * 100
+}
+
+// isProcessGone сообщает, что процесс завершился между получением списка PID и чтением его данных.
+func isProcessGone(err error) bool {
+	return err != nil && (errors.Is(err, fs.ErrNotExist) || errors.Is(err, process.ErrorProcessNotRunning))
+}
+
+// groupConnectionsByPID раскладывает соединения по PID владельца.
+func groupConnectionsByPID(allConnections []net.ConnectionStat) map[int32][]net.ConnectionStat {
+	connectionsByPID := make(map[int32][]net.ConnectionStat)
+	for _, conn := range allConnections {
+		if conn.Pid > 0 {
+			connectionsByPID[conn.Pid] = append(connectionsByPID[conn.Pid], conn)
+		}
+	}
+	return connectionsByPID
+}
+
+// portsOf возвращает уникальные локальные порты соединений процесса по возрастанию: порядок
+// не зависит от обхода map, и неизменившийся процесс не попадает в разницу снимков из-за портов.
+func portsOf(connections []net.ConnectionStat) []uint32 {
+	portMap := make(map[uint32]bool)
+	for _, conn := range connections {
+		if conn.Laddr.Port > 0 {
+			portMap[conn.Laddr.Port] = true
+		}
+	}
+
+	ports := make([]uint32, 0, len(portMap))
+	for port := range portMap {
+		ports = append(ports, port)
+	}
+	slices.Sort(ports)
+	return ports
+}
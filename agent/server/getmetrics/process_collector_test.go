@@ -0,0 +1,71 @@
+package getmetrics
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+// Бенчмарки сравнивают полный опрос процессов (первый цикл сборщика, кэш пуст) с установившимся режимом.
+// Запуск: go test -run=^$ -bench=. -benchmem ./server/getmetrics
+
+// BenchmarkProcessCollectorCold - первый цикл нового сборщика: все поля каждого процесса читаются заново.
+func BenchmarkProcessCollectorCold(b *testing.B) {
+	for b.Loop() {
+		if _, err := NewProcessCollector().Collect([]net.ConnectionStat{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkProcessCollectorWarm - установившийся режим: неизменяемые поля берутся из кэша.
+func BenchmarkProcessCollectorWarm(b *testing.B) {
+	collector := NewProcessCollector()
+	if _, err := collector.Collect([]net.ConnectionStat{}); err != nil {
+		b.Fatal(err)
+	}
+
+	for b.Loop() {
+		if _, err := collector.Collect([]net.ConnectionStat{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// listenConn возвращает соединение процесса с локальным портом port.
+func listenConn(port uint32) net.ConnectionStat {
+	return net.ConnectionStat{Pid: 1000, Laddr: net.Addr{IP: "0.0.0.0", Port: port}}
+}
+
+func TestPortsOf(t *testing.T) {
+	tests := []struct {
+		name        string
+		connections []net.ConnectionStat
+		want        []uint32
+	}{
+		{name: "no connections", connections: nil, want: []uint32{}},
+		{name: "one port", connections: []net.ConnectionStat{listenConn(80)}, want: []uint32{80}},
+		{
+			name:        "sorted ports",
+			connections: []net.ConnectionStat{listenConn(8443), listenConn(22), listenConn(80), listenConn(443)},
+			want:        []uint32{22, 80, 443, 8443},
+		},
+		{
+			name:        "duplicates and unbound sockets",
+			connections: []net.ConnectionStat{listenConn(443), listenConn(0), listenConn(80), listenConn(443)},
+			want:        []uint32{80, 443},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Порты собираются через map, поэтому вызов повторяется: порядок не должен меняться.
+			for range 20 {
+				if got := portsOf(tt.connections); !slices.Equal(got, tt.want) {
+					t.Fatalf("portsOf() = %v, ожидалось %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+//go:build linux
+
+package getmetrics
+
+import (
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// memoryMapsSummary суммирует отображения памяти процесса из /proc/<pid>/smaps.
+func memoryMapsSummary(proc *process.Process) (*models.ProcessMemoryMaps, error) {
+	maps, err := proc.MemoryMaps(false)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.ProcessMemoryMaps{Mappings: len(*maps)}
+	// gopsutil отдаёт размеры из smaps в килобайтах.
+	const kib = 1024
+	for _, m := range *maps {
+		summary.Size += m.Size * kib
+		summary.RSS += m.Rss * kib
+		summary.PSS += m.Pss * kib
+		summary.SharedClean += m.SharedClean * kib
+		summary.SharedDirty += m.SharedDirty * kib
+		summary.PrivateClean += m.PrivateClean * kib
+		summary.PrivateDirty += m.PrivateDirty * kib
+		summary.Anonymous += m.Anonymous * kib
+		summary.Swap += m.Swap * kib
+	}
+	return summary, nil
+}
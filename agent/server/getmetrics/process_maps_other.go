@@ -0,0 +1,15 @@
+//go:build !linux
+
+package getmetrics
+
+import (
+	"errors"
+
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// memoryMapsSummary доступна только в Linux: на других системах gopsutil не разбирает карты памяти.
+func memoryMapsSummary(_ *process.Process) (*models.ProcessMemoryMaps, error) {
+	return nil, errors.New("карты памяти доступны только в Linux")
+}
@@ -0,0 +1,207 @@
+package getmetrics
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/RZhurakovskiy/agent/server/models"
+)
+
+// processStatuses - состояния процессов, которые возвращает gopsutil.
+var processStatuses = []string{"running", "sleep", "stop", "idle", "zombie", "wait", "lock"}
+
+// processSortKeys - поля ProcessInfo (имена из JSON), по которым можно сортировать список.
+var processSortKeys = map[string]func(a, b models.ProcessInfo) int{
+	"pid": func(a, b models.ProcessInfo) int { return cmp.Compare(a.PID, b.PID) },
+	"name": func(a, b models.ProcessInfo) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	},
+	"username":       func(a, b models.ProcessInfo) int { return strings.Compare(a.Username, b.Username) },
+	"status":         func(a, b models.ProcessInfo) int { return strings.Compare(a.Status, b.Status) },
+	"createTime":     func(a, b models.ProcessInfo) int { return cmp.Compare(a.CreateTime, b.CreateTime) },
+	"cpuPercent":     func(a, b models.ProcessInfo) int { return cmp.Compare(a.CPUPercent, b.CPUPercent) },
+	"cpuHostPercent": func(a, b models.ProcessInfo) int { return cmp.Compare(a.CPUHostPercent, b.CPUHostPercent) },
+	"memoryPercent":  func(a, b models.ProcessInfo) int { return cmp.Compare(a.MemoryPercent, b.MemoryPercent) },
+	"memoryRss":      func(a, b models.ProcessInfo) int { return cmp.Compare(a.MemoryRSS, b.MemoryRSS) },
+	"memoryVms":      func(a, b models.ProcessInfo) int { return cmp.Compare(a.MemoryVMS, b.MemoryVMS) },
+}
+
+// ProcessQuery - выборка из списка процессов: фильтры, сортировка и окно.
+// Нулевые значения полей - без ограничения; nil-границы диапазонов не проверяются.
+type ProcessQuery struct {
+	PID       int32
+	Name      string         // Подстрока имени без учёта регистра
+	NameRegex *regexp.Regexp // Регулярное выражение для имени
+	User      string         // Имя пользователя без учёта регистра
+	Port      uint32         // Процесс использует локальный порт
+	Statuses  []string
+	CPUMin    *float64 // Границы cpuPercent включительно
+	CPUMax    *float64
+	MemoryMin *float64 // Границы RSS в мегабайтах включительно
+	MemoryMax *float64
+	Sort      string // Поле сортировки из processSortKeys; пусто - порядок сбора
+	Desc      bool
+	Limit     int // 0 - без ограничения
+	Offset    int
+}
+
+// ParseProcessQuery разбирает выборку процессов из query-параметров: pid, name (подстрока),
+// nameRegex, user, port, status (running, sleep, ...; через запятую), cpuMin/cpuMax (cpuPercent),
+// memoryMin/memoryMax (RSS в МБ), sort (поле процесса), order (asc, desc), limit и offset.
+func ParseProcessQuery(query url.Values) (ProcessQuery, error) {
+	var q ProcessQuery
+
+	if value := query.Get("pid"); value != "" {
+		pid, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || pid <= 0 {
+			return q, fmt.Errorf("некорректное значение параметра pid: %q", value)
+		}
+		q.PID = int32(pid)
+	}
+
+	q.Name = strings.ToLower(query.Get("name"))
+	if value := query.Get("nameRegex"); value != "" {
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return q, fmt.Errorf("некорректное значение параметра nameRegex: %v", err)
+		}
+		q.NameRegex = re
+	}
+	q.User = query.Get("user")
+
+	if value := query.Get("port"); value != "" {
+		port, err := strconv.ParseUint(value, 10, 16)
+		if err != nil || port == 0 {
+			return q, fmt.Errorf("некорректное значение параметра port: %q", value)
+		}
+		q.Port = uint32(port)
+	}
+
+	statuses, err := ParseListParam(query, "status", processStatuses)
+	if err != nil {
+		return q, err
+	}
+	q.Statuses = statuses
+
+	for name, bound := range map[string]**float64{
+		"cpuMin": &q.CPUMin, "cpuMax": &q.CPUMax, "memoryMin": &q.MemoryMin, "memoryMax": &q.MemoryMax,
+	} {
+		if value := query.Get(name); value != "" {
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil || n < 0 {
+				return q, fmt.Errorf("некорректное значение параметра %s: %q", name, value)
+			}
+			*bound = &n
+		}
+	}
+	if q.CPUMin != nil && q.CPUMax != nil && *q.CPUMin > *q.CPUMax {
+		return q, fmt.Errorf("cpuMin не может быть больше cpuMax")
+	}
+	if q.MemoryMin != nil && q.MemoryMax != nil && *q.MemoryMin > *q.MemoryMax {
+		return q, fmt.Errorf("memoryMin не может быть больше memoryMax")
+	}
+
+	if value := query.Get("sort"); value != "" {
+		if _, ok := processSortKeys[value]; !ok {
+			keys := slices.Sorted(maps.Keys(processSortKeys))
+			return q, fmt.Errorf("некорректное значение параметра sort: %q, допустимо: %s", value, strings.Join(keys, ", "))
+		}
+		q.Sort = value
+	}
+	switch value := query.Get("order"); value {
+	case "", "asc":
+	case "desc":
+		q.Desc = true
+	default:
+		return q, fmt.Errorf("некорректное значение параметра order: %q, допустимо: asc, desc", value)
+	}
+
+	for name, n := range map[string]*int{"limit": &q.Limit, "offset": &q.Offset} {
+		if value := query.Get(name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 {
+				return q, fmt.Errorf("некорректное значение параметра %s: %q", name, value)
+			}
+			*n = parsed
+		}
+	}
+
+	return q, nil
+}
+
+// IsZero сообщает, что выборка не задана: ни фильтров, ни сортировки, ни окна.
+func (q ProcessQuery) IsZero() bool {
+	return q.PID == 0 && q.Name == "" && q.NameRegex == nil && q.User == "" && q.Port == 0 &&
+		len(q.Statuses) == 0 && q.CPUMin == nil && q.CPUMax == nil && q.MemoryMin == nil && q.MemoryMax == nil &&
+		q.Sort == "" && !q.Desc && q.Limit == 0 && q.Offset == 0
+}
+
+// Match сообщает, подходит ли процесс под фильтры выборки.
+func (q ProcessQuery) Match(p models.ProcessInfo) bool {
+	if q.PID != 0 && p.PID != q.PID {
+		return false
+	}
+	if q.Name != "" && !strings.Contains(strings.ToLower(p.Name), q.Name) {
+		return false
+	}
+	if q.NameRegex != nil && !q.NameRegex.MatchString(p.Name) {
+		return false
+	}
+	if q.User != "" && !strings.EqualFold(p.Username, q.User) {
+		return false
+	}
+	if q.Port != 0 && !slices.Contains(p.Ports, q.Port) {
+		return false
+	}
+	if len(q.Statuses) > 0 && !slices.Contains(q.Statuses, p.Status) {
+		return false
+	}
+	if !inRange(p.CPUPercent, q.CPUMin, q.CPUMax) {
+		return false
+	}
+	return inRange(float64(p.MemoryRSS)/1024/1024, q.MemoryMin, q.MemoryMax)
+}
+
+// inRange проверяет value на вхождение в диапазон [low, high]; nil-граница не проверяется.
+func inRange(value float64, low, high *float64) bool {
+	return (low == nil || value >= *low) && (high == nil || value <= *high)
+}
+
+// QueryProcesses фильтрует и сортирует процессы и возвращает окно выборки. procs не изменяется.
+// Процессы с равным значением поля сортировки упорядочиваются по PID.
+func QueryProcesses(procs []models.ProcessInfo, q ProcessQuery) models.ProcessPage {
+	matched := make([]models.ProcessInfo, 0, len(procs))
+	for _, p := range procs {
+		if q.Match(p) {
+			matched = append(matched, p)
+		}
+	}
+
+	if compare, ok := processSortKeys[q.Sort]; ok {
+		slices.SortFunc(matched, func(a, b models.ProcessInfo) int {
+			c := compare(a, b)
+			if q.Desc {
+				c = -c
+			}
+			if c == 0 {
+				c = cmp.Compare(a.PID, b.PID)
+			}
+			return c
+		})
+	}
+
+	page := models.ProcessPage{Total: len(matched), Offset: q.Offset, Limit: q.Limit}
+	start := min(q.Offset, len(matched))
+	end := len(matched)
+	if q.Limit > 0 {
+		end = min(start+q.Limit, end)
+	}
+	page.Processes = matched[start:end]
+	return page
+}
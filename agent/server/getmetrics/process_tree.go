@@ -0,0 +1,117 @@
+package getmetrics
+
+import (
+	"sort"
+
+	"github.com/RZhurakovskiy/agent/server/models"
+)
+
+// BuildProcessTree строит дерево процессов по ParentPID и считает суммарные CPU и RSS поддеревьев.
+//
+// Процесс становится корнем, если его родителя нет в снимке, родитель - он сам (PID 0 в Windows)
+// или родитель запущен позже него: значит, PID родителя уже переиспользован другим процессом.
+// Процессы, переназначенные ядром после смерти родителя (к init или subreaper), уже имеют
+// нового ParentPID и попадают под него. Дети каждого узла и корни упорядочены по PID.
+func BuildProcessTree(procs []models.ProcessInfo) []*models.ProcessNode {
+	nodes := make(map[int32]*models.ProcessNode, len(procs))
+	for _, p := range procs {
+		nodes[p.PID] = &models.ProcessNode{ProcessInfo: p, Children: []*models.ProcessNode{}}
+	}
+
+	roots := make([]*models.ProcessNode, 0)
+	for _, node := range nodes {
+		parent, ok := nodes[node.ParentPID]
+		switch {
+		case node.ParentPID == node.PID || node.ParentPID <= 0:
+			roots = append(roots, node)
+		case !ok || isReusedParentPID(parent, node):
+			node.Orphaned = true
+			roots = append(roots, node)
+		default:
+			parent.Children = append(parent.Children, node)
+		}
+	}
+
+	// Защита от циклов (возможны в Windows при переиспользовании PID с одинаковым временем запуска):
+	// узлы, недостижимые из корней, отцепляются от родителей и становятся корнями.
+	visited := make(map[int32]bool, len(nodes))
+	for _, root := range roots {
+		aggregateSubtree(root, visited)
+	}
+	for _, p := range procs {
+		node := nodes[p.PID]
+		if visited[node.PID] {
+			continue
+		}
+		if parent, ok := nodes[node.ParentPID]; ok {
+			parent.Children = removeChild(parent.Children, node)
+		}
+		node.Orphaned = true
+		roots = append(roots, node)
+		aggregateSubtree(node, visited)
+	}
+
+	sortNodes(roots)
+	return roots
+}
+
+// FindProcessNode ищет в дереве узел с указанным PID.
+func FindProcessNode(roots []*models.ProcessNode, pid int32) *models.ProcessNode {
+	for _, node := range roots {
+		if node.PID == pid {
+			return node
+		}
+		if found := FindProcessNode(node.Children, pid); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// isReusedParentPID сообщает, что процесс с PID родителя запущен позже потомка,
+// то есть настоящий родитель завершился, а его PID достался новому процессу.
+func isReusedParentPID(parent, child *models.ProcessNode) bool {
+	return parent.CreateTime > 0 && child.CreateTime > 0 && parent.CreateTime > child.CreateTime
+}
+
+// aggregateSubtree считает суммы по поддереву и отмечает его узлы как посещённые.
+// Уже посещённые дети отцепляются - так разрывается цикл, если он есть.
+func aggregateSubtree(node *models.ProcessNode, visited map[int32]bool) {
+	visited[node.PID] = true
+
+	node.SubtreeCPUPercent = node.CPUPercent
+	node.SubtreeCPUHostPercent = node.CPUHostPercent
+	node.SubtreeMemoryRSS = node.MemoryRSS
+	node.SubtreeProcesses = 1
+
+	children := node.Children[:0]
+	for _, child := range node.Children {
+		if visited[child.PID] {
+			continue
+		}
+		aggregateSubtree(child, visited)
+		node.SubtreeCPUPercent += child.SubtreeCPUPercent
+		node.SubtreeCPUHostPercent += child.SubtreeCPUHostPercent
+		node.SubtreeMemoryRSS += child.SubtreeMemoryRSS
+		node.SubtreeProcesses += child.SubtreeProcesses
+		children = append(children, child)
+	}
+	node.Children = children
+
+	sortNodes(node.Children)
+}
+
+// removeChild удаляет узел из списка детей.
+func removeChild(children []*models.ProcessNode, target *models.ProcessNode) []*models.ProcessNode {
+	for i, child := range children {
+		if child == target {
+			return append(children[:i], children[i+1:]...)
+		}
+	}
+	return children
+}
+
+// sortNodes упорядочивает узлы по PID.
+func sortNodes(nodes []*models.ProcessNode) {
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].PID < nodes[j].PID })
+}
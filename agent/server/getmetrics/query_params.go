@@ -0,0 +1,29 @@
+package getmetrics
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// ParseListParam разбирает список значений из query-параметра: через запятую и/или повтором
+// параметра (?state=listen,established или ?state=listen&state=established). Значения приводятся
+// к нижнему регистру; если allowed не пуст, каждое значение должно входить в allowed.
+// Пустой параметр даёт пустой список.
+func ParseListParam(query url.Values, name string, allowed []string) ([]string, error) {
+	var values []string
+	for _, raw := range query[name] {
+		for _, value := range strings.Split(raw, ",") {
+			value = strings.ToLower(strings.TrimSpace(value))
+			if value == "" {
+				continue
+			}
+			if len(allowed) > 0 && !slices.Contains(allowed, value) {
+				return nil, fmt.Errorf("некорректное значение параметра %s: %q, допустимо: %s", name, value, strings.Join(allowed, ", "))
+			}
+			values = append(values, value)
+		}
+	}
+	return values, nil
+}
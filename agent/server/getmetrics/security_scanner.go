@@ -0,0 +1,406 @@
+package getmetrics
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+const (
+	// securityPrimeInterval - интервал между двумя снимками процессов, когда свежего предыдущего
+	// снимка нет, чтобы CPU% считался за этот интервал, а не был нулевым.
+	securityPrimeInterval = time.Second
+	// securityBaselineMaxAge - предыдущий снимок старше этого заново снимается перед проверкой:
+	// иначе CPU% усреднялся бы за всё время с прошлой проверки.
+	securityBaselineMaxAge = 5 * time.Second
+
+	// minerCPUThreshold - загрузка (100% = одно ядро), начиная с которой неизвестный бинарник считается нагружающим CPU.
+	minerCPUThreshold = 80.0
+
+	// firstRegularUID - первый UID обычных пользователей; системные сервисы работают под UID ниже
+	// (или под nobody).
+	firstRegularUID = 1000
+	nobodyUID       = 65534
+
+	deletedSuffix = " (deleted)"
+)
+
+var (
+	// trustedExeDirs - каталоги, в которые исполняемые файлы устанавливает пакетный менеджер или администратор.
+	trustedExeDirs = []string{
+		"/usr/", "/bin/", "/sbin/", "/lib/", "/lib64/", "/opt/", "/snap/", "/nix/store/",
+		`c:\windows\`, `c:\program files\`, `c:\program files (x86)\`,
+	}
+	// tempExeDirs - каталоги, доступные на запись всем: отсюда часто запускается вредоносный код.
+	tempExeDirs = []string{"/tmp/", "/var/tmp/", "/dev/shm/", "/run/shm/"}
+	// knownHiddenDirs - скрытые каталоги, куда ставят инструменты разработчика (pipx, cargo, nvm и т.д.).
+	knownHiddenDirs = []string{
+		".local", ".cargo", ".rustup", ".nvm", ".pyenv", ".sdkman", ".bun", ".deno",
+		".vscode-server", ".cursor-server", ".dotnet", ".npm", ".yarn", ".volta", ".asdf", ".ghcup", ".juliaup",
+	}
+	// kernelThreadNames - префиксы имён потоков ядра и системных демонов, под которые маскируются вредоносные процессы.
+	kernelThreadNames = []string{
+		"kworker", "kthreadd", "ksoftirqd", "kswapd", "migration", "rcu_", "watchdog", "kauditd", "khugepaged",
+		"systemd", "sshd", "cron", "crond", "dbus-daemon", "rsyslogd", "udevd", "init", "agetty",
+	}
+	// commonListenPorts - порты, которые обычно слушают сервисы и инструменты разработки.
+	commonListenPorts = map[uint32]bool{
+		22: true, 53: true, 80: true, 443: true, 631: true, 3000: true, 3001: true, 4200: true, 5000: true,
+		5173: true, 5353: true, 5432: true, 6379: true, 8000: true, 8080: true, 8081: true, 8443: true,
+		8888: true, 9000: true, 9090: true, 3306: true, 27017: true,
+	}
+	// backdoorPorts - порты, которые по умолчанию используют бэкдоры и reverse shell.
+	backdoorPorts = map[uint32]bool{
+		1337: true, 4444: true, 4445: true, 5554: true, 6666: true, 6667: true, 12345: true, 31337: true, 54321: true,
+	}
+	// minerPattern - признаки майнеров криптовалют в имени процесса и командной строке.
+	minerPattern = regexp.MustCompile(`(?i)(xmrig|xmr-stak|minerd|cpuminer|ccminer|nbminer|phoenixminer|lolminer|nanominer|t-rex|stratum\+(tcp|ssl)://|--donate-level|cryptonight|randomx)`)
+)
+
+// ProcessVerifier заполняет у процессов снимка уже посчитанные хэши и вердикты проверки целостности,
+// не читая исполняемые файлы (реализуется services.IntegrityChecker).
+type ProcessVerifier interface {
+	VerifyCached(procs []models.ProcessInfo, now time.Time)
+}
+
+// SecurityScanner проверяет процессы эвристиками подозрительной активности.
+// Хранит CPU-время процессов между проверками, чтобы считать CPU% за интервал.
+type SecurityScanner struct {
+	mu        sync.Mutex
+	collector *ProcessCollector
+	verifier  ProcessVerifier
+	sampledAt time.Time // Время последнего снимка процессов - точки отсчёта CPU-времени
+}
+
+// NewSecurityScanner создаёт сканер подозрительных процессов.
+func NewSecurityScanner() *SecurityScanner {
+	return &SecurityScanner{collector: NewProcessCollector()}
+}
+
+// SetVerifier подключает проверку целостности: процессы с запрещённым исполняемым файлом
+// попадают в отчёт с находкой denied-exe.
+func (s *SecurityScanner) SetVerifier(verifier ProcessVerifier) {
+	s.mu.Lock()
+	s.verifier = verifier
+	s.mu.Unlock()
+}
+
+// Scan снимает процессы и сокеты и проверяет их эвристиками. Если предыдущего снимка нет или он
+// старше securityBaselineMaxAge, процессы снимаются дважды с интервалом securityPrimeInterval,
+// чтобы загрузка CPU считалась за короткий интервал.
+// Если подключена проверка целостности, перед эвристиками процессам проставляются вердикты по уже
+// посчитанным хэшам; остальные файлы хэшируются в фоне и учитываются в PendingHashes.
+func (s *SecurityScanner) Scan() (models.SecurityReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(s.sampledAt) > securityBaselineMaxAge {
+		if _, err := s.collector.Collect([]net.ConnectionStat{}); err != nil {
+			return models.SecurityReport{}, err
+		}
+		time.Sleep(securityPrimeInterval)
+	}
+
+	conns, err := net.Connections("inet")
+	if err != nil {
+		return models.SecurityReport{}, fmt.Errorf("не удалось получить сетевые соединения: %w", err)
+	}
+	procs, err := s.collector.Collect(conns)
+	if err != nil {
+		return models.SecurityReport{}, err
+	}
+	s.sampledAt = time.Now()
+
+	if s.verifier == nil {
+		return DetectSuspiciousProcesses(procs, conns), nil
+	}
+	s.verifier.VerifyCached(procs, s.sampledAt)
+	report := DetectSuspiciousProcesses(procs, conns)
+	report.PendingHashes = PendingHashes(procs)
+	return report, nil
+}
+
+// PendingHashes возвращает число процессов, у которых исполняемый файл известен, а хэш ещё не посчитан.
+func PendingHashes(procs []models.ProcessInfo) int {
+	pending := 0
+	for _, p := range procs {
+		if p.Exe != "" && p.SHA256 == "" {
+			pending++
+		}
+	}
+	return pending
+}
+
+// DetectSuspiciousProcesses проверяет снимок процессов и их сокеты эвристиками:
+//   - исполняемый файл удалён, а процесс работает (deleted-exe);
+//   - исполняемый файл в списке запрещённых проверки целостности (denied-exe);
+//   - запуск из /tmp, /dev/shm (temp-exe) или скрытого каталога (hidden-dir-exe);
+//   - имя процесса не совпадает с исполняемым файлом (name-mismatch);
+//   - обычный пользователь слушает необычный порт на внешнем адресе (unusual-listener);
+//   - процесс root с сетевыми сокетами запущен не из системного каталога (root-network);
+//   - неизвестный бинарник нагружает CPU или похож на майнер (cpu-miner).
+//
+// Путь исполняемого файла перечитывается из /proc/<pid>/exe, потому что в снимке он может быть устаревшим.
+func DetectSuspiciousProcesses(procs []models.ProcessInfo, conns []net.ConnectionStat) models.SecurityReport {
+	connsByPID := groupConnectionsByPID(conns)
+
+	var findings []models.SecurityFinding
+	for _, p := range procs {
+		exe := currentExe(p)
+		add := func(rule, severity, title, explanation string) {
+			findings = append(findings, models.SecurityFinding{
+				Rule:        rule,
+				Severity:    severity,
+				PID:         p.PID,
+				Name:        p.Name,
+				Exe:         exe,
+				Username:    p.Username,
+				CPUPercent:  p.CPUPercent,
+				Title:       title,
+				Explanation: explanation,
+			})
+		}
+
+		deleted := strings.HasSuffix(exe, deletedSuffix)
+		exePath := strings.TrimSuffix(exe, deletedSuffix)
+		trusted := hasAnyPrefix(strings.ToLower(exePath), trustedExeDirs)
+
+		switch {
+		case deleted && strings.HasPrefix(exePath, "/memfd:"):
+			add("deleted-exe", models.SeverityCritical, "Процесс запущен из памяти (memfd)",
+				"Исполняемый файл существует только в памяти (memfd_create) и не имеет пути на диске. Так работают бесфайловые загрузчики вредоносного кода.")
+		case deleted && trusted:
+			add("deleted-exe", models.SeverityLow, "Исполняемый файл удалён",
+				fmt.Sprintf("Файл %s удалён после запуска процесса. Для системного каталога это обычно значит, что пакет обновили, а процесс не перезапустили.", exePath))
+		case deleted:
+			add("deleted-exe", models.SeverityHigh, "Исполняемый файл удалён",
+				fmt.Sprintf("Файл %s удалён после запуска процесса. Вредоносные программы удаляют себя с диска, чтобы скрыть следы.", exePath))
+		}
+
+		if p.Verdict == models.IntegrityDenied {
+			add("denied-exe", models.SeverityCritical, "Исполняемый файл в списке запрещённых",
+				fmt.Sprintf("Хэш (SHA-256 %s) или путь исполняемого файла %s внесён администратором в список запрещённых.", p.SHA256, exePath))
+		}
+
+		if dir := firstPrefix(exePath, tempExeDirs); dir != "" {
+			add("temp-exe", models.SeverityHigh, "Запуск из временного каталога",
+				fmt.Sprintf("Процесс запущен из %s - каталога, доступного на запись всем пользователям. Легитимные программы устанавливаются в системные каталоги.", dir))
+		} else if dir := hiddenDir(exePath); dir != "" {
+			add("hidden-dir-exe", models.SeverityMedium, "Запуск из скрытого каталога",
+				fmt.Sprintf("Исполняемый файл находится в скрытом каталоге %s. Так часто прячут вредоносные программы в домашних каталогах.", dir))
+		}
+
+		if exePath != "" && p.Name != "" && !strings.HasPrefix(exePath, "/memfd:") {
+			exeName := path.Base(strings.ReplaceAll(exePath, `\`, "/"))
+			// Имя процесса в Linux обрезается до 15 символов, а у интерпретаторов бывает без версии (python3 и python3.12).
+			mismatch := !strings.HasPrefix(exeName, p.Name) && !strings.HasPrefix(p.Name, exeName)
+			switch {
+			case !trusted && hasAnyPrefix(strings.Trim(p.Name, "[]()"), kernelThreadNames):
+				// У настоящих потоков ядра нет исполняемого файла, а системные демоны лежат в системных каталогах.
+				add("name-mismatch", models.SeverityHigh, "Процесс маскируется под системный",
+					fmt.Sprintf("Процесс называется %q, как поток ядра или системный демон, но запущен из %s.", p.Name, exePath))
+			case mismatch && !trusted:
+				// В системных каталогах расхождение обычно даёт запуск через символическую ссылку (sh -> dash).
+				add("name-mismatch", models.SeverityMedium, "Имя процесса не совпадает с исполняемым файлом",
+					fmt.Sprintf("Процесс называется %q, а запущен из %s. Некоторые программы сами меняют имя процесса, но так же поступают и вредоносные.", p.Name, exePath))
+			}
+		}
+
+		uid, hasUID := effectiveUID(connsByPID[p.PID])
+
+		if ports, backdoor := unusualListenPorts(connsByPID[p.PID]); hasUID && isRegularUser(uid) && len(ports) > 0 {
+			severity := models.SeverityMedium
+			if backdoor {
+				severity = models.SeverityHigh
+			}
+			add("unusual-listener", severity, "Пользовательский процесс слушает необычный порт",
+				fmt.Sprintf("Процесс пользователя с UID %d принимает соединения на внешнем адресе: %s. Сервисы обычно работают под системными пользователями, а такие порты используют бэкдоры и reverse shell.", uid, strings.Join(ports, ", ")))
+		}
+
+		if hasUID && uid == 0 && exePath != "" && !trusted && hasInetSocket(connsByPID[p.PID]) {
+			severity := models.SeverityMedium
+			explanation := fmt.Sprintf("Процесс root с сетевыми сокетами запущен из %s, а не из системного каталога.", exePath)
+			if p.ContainerID != "" {
+				// В контейнерах приложения часто лежат вне /usr и работают под root.
+				severity = models.SeverityLow
+				explanation += " Процесс работает в контейнере, где это встречается часто."
+			}
+			add("root-network", severity, "Неожиданный процесс root с сетевыми сокетами", explanation)
+		}
+
+		switch signature := minerPattern.FindString(p.Name + " " + p.Cmdline); {
+		case signature != "" && p.CPUPercent >= minerCPUThreshold:
+			add("cpu-miner", models.SeverityCritical, "Майнер криптовалют",
+				fmt.Sprintf("Имя или командная строка содержат признаки майнера (%s), и процесс загружает CPU на %.0f%%.", signature, p.CPUPercent))
+		case signature != "":
+			add("cpu-miner", models.SeverityHigh, "Признаки майнера криптовалют",
+				fmt.Sprintf("Имя или командная строка содержат признаки майнера (%s), загрузка CPU %.0f%%. Майнеры часто ограничивают нагрузку, чтобы не привлекать внимания.", signature, p.CPUPercent))
+		case p.CPUPercent >= minerCPUThreshold && exePath != "" && !trusted:
+			add("cpu-miner", models.SeverityMedium, "Неизвестный бинарник нагружает CPU",
+				fmt.Sprintf("Процесс из %s загружает CPU на %.0f%% (100%% = одно ядро). Постоянная нагрузка от программы вне системных каталогов - типичный признак майнера.", exePath, p.CPUPercent))
+		}
+	}
+
+	report := models.SecurityReport{
+		Scanned:    len(procs),
+		BySeverity: map[string]int{},
+		Findings:   []models.SecurityFinding{},
+		Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+	}
+	for _, finding := range findings {
+		report.BySeverity[finding.Severity]++
+	}
+	for _, p := range procs {
+		if p.Verdict != "" {
+			if report.Integrity == nil {
+				report.Integrity = make(map[string]int)
+			}
+			report.Integrity[p.Verdict]++
+		}
+	}
+	if findings != nil {
+		report.Findings = findings
+	}
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if SeverityRank(a.Severity) != SeverityRank(b.Severity) {
+			return SeverityRank(a.Severity) > SeverityRank(b.Severity)
+		}
+		return a.PID < b.PID
+	})
+	return report
+}
+
+// SeverityRank возвращает порядковый номер уровня серьёзности (0 - неизвестный уровень).
+func SeverityRank(severity string) int {
+	switch severity {
+	case models.SeverityLow:
+		return 1
+	case models.SeverityMedium:
+		return 2
+	case models.SeverityHigh:
+		return 3
+	case models.SeverityCritical:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// FilterFindings оставляет находки с серьёзностью не ниже minSeverity.
+func FilterFindings(findings []models.SecurityFinding, minSeverity string) []models.SecurityFinding {
+	minRank := SeverityRank(minSeverity)
+	result := make([]models.SecurityFinding, 0, len(findings))
+	for _, finding := range findings {
+		if SeverityRank(finding.Severity) >= minRank {
+			result = append(result, finding)
+		}
+	}
+	return result
+}
+
+// currentExe перечитывает путь исполняемого файла из /proc/<pid>/exe: в снимке процессов он кэшируется
+// и может не отражать удаление файла. Если ссылка недоступна (нет прав, не Linux), берётся путь из снимка.
+func currentExe(p models.ProcessInfo) string {
+	if exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", p.PID)); err == nil {
+		return exe
+	}
+	return p.Exe
+}
+
+// hiddenDir возвращает скрытый каталог (имя начинается с точки) в пути исполняемого файла,
+// кроме известных каталогов инструментов разработчика.
+func hiddenDir(exePath string) string {
+	parts := strings.Split(strings.ReplaceAll(exePath, `\`, "/"), "/")
+	for i, part := range parts[:max(len(parts)-1, 0)] {
+		if len(part) < 2 || part[0] != '.' || part == ".." {
+			continue
+		}
+		for _, known := range knownHiddenDirs {
+			if part == known {
+				return ""
+			}
+		}
+		return strings.Join(parts[:i+1], "/")
+	}
+	return ""
+}
+
+// effectiveUID возвращает эффективный UID владельца сокетов процесса. UID известен только
+// для процессов с сокетами и только в Linux.
+func effectiveUID(conns []net.ConnectionStat) (int32, bool) {
+	for _, conn := range conns {
+		if len(conn.Uids) > 1 {
+			return conn.Uids[1], true
+		}
+	}
+	return 0, false
+}
+
+// isRegularUser сообщает, что UID принадлежит обычному пользователю, а не системному сервису.
+func isRegularUser(uid int32) bool {
+	return uid >= firstRegularUID && uid != nobodyUID
+}
+
+// hasInetSocket сообщает, есть ли у процесса TCP- или UDP-сокеты.
+func hasInetSocket(conns []net.ConnectionStat) bool {
+	for _, conn := range conns {
+		if conn.Family != afUnix {
+			return true
+		}
+	}
+	return false
+}
+
+// unusualListenPorts возвращает необычные порты (вида "4444/tcp"), которые процесс слушает
+// на внешних адресах, и сообщает, есть ли среди них порты бэкдоров.
+func unusualListenPorts(conns []net.ConnectionStat) ([]string, bool) {
+	var ports []string
+	backdoor := false
+	seen := make(map[string]bool)
+	for _, conn := range conns {
+		protocol := connectionType(conn.Family, conn.Type)
+		listening := (protocol == "tcp" && conn.Status == "LISTEN") || (protocol == "udp" && conn.Raddr.Port == 0)
+		if !listening || conn.Laddr.Port == 0 || commonListenPorts[conn.Laddr.Port] {
+			continue
+		}
+		if addr, err := netip.ParseAddr(conn.Laddr.IP); err == nil && addr.Unmap().IsLoopback() {
+			continue
+		}
+
+		port := fmt.Sprintf("%d/%s", conn.Laddr.Port, protocol)
+		if seen[port] {
+			continue
+		}
+		seen[port] = true
+		ports = append(ports, port)
+		backdoor = backdoor || backdoorPorts[conn.Laddr.Port]
+	}
+	sort.Strings(ports)
+	return ports, backdoor
+}
+
+// hasAnyPrefix сообщает, начинается ли строка с одного из префиксов.
+func hasAnyPrefix(s string, prefixes []string) bool {
+	return firstPrefix(s, prefixes) != ""
+}
+
+// firstPrefix возвращает первый из префиксов, с которого начинается строка, или пустую строку.
+func firstPrefix(s string, prefixes []string) string {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return prefix
+		}
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+package getmetrics
+
+import (
+	"net"
+	"strconv"
+)
+
+// Семейства адресов и типы сокетов в том виде, в каком их возвращает gopsutil.
+const (
+	afUnix         = 1
+	afInet         = 2
+	afInet6        = 10 // Linux
+	afInet6Windows = 23
+	afInet6BSD     = 30 // macOS
+
+	sockStream    = 1
+	sockDgram     = 2
+	sockSeqpacket = 5
+)
+
+// connectionFamily возвращает имя семейства адресов сокета: "ipv4", "ipv6" или "unix".
+func connectionFamily(family uint32) string {
+	switch family {
+	case afInet:
+		return "ipv4"
+	case afInet6, afInet6Windows, afInet6BSD:
+		return "ipv6"
+	case afUnix:
+		return "unix"
+	default:
+		return strconv.FormatUint(uint64(family), 10)
+	}
+}
+
+// connectionType возвращает протокол сокета: "tcp"/"udp" для IP и "stream"/"dgram"/"seqpacket" для UNIX-сокетов.
+func connectionType(family, sockType uint32) string {
+	unix := family == afUnix
+	switch {
+	case sockType == sockStream && unix:
+		return "stream"
+	case sockType == sockStream:
+		return "tcp"
+	case sockType == sockDgram && unix:
+		return "dgram"
+	case sockType == sockDgram:
+		return "udp"
+	case sockType == sockSeqpacket && unix:
+		return "seqpacket"
+	default:
+		return strconv.FormatUint(uint64(sockType), 10)
+	}
+}
+
+// formatAddr форматирует адрес сокета: "ip:port" (IPv6 - в квадратных скобках) или путь UNIX-сокета.
+// Пустой адрес (нет удалённой стороны) возвращается как пустая строка.
+func formatAddr(family uint32, ip string, port uint32) string {
+	if family == afUnix {
+		return ip
+	}
+	if ip == "" && port == 0 {
+		return ""
+	}
+	return net.JoinHostPort(ip, strconv.FormatUint(uint64(port), 10))
+}
@@ -0,0 +1,156 @@
+package getmetrics
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/models"
+)
+
+const (
+	// tcpStackPrimeInterval - интервал первого замера счётчиков, когда предыдущих ещё нет.
+	tcpStackPrimeInterval = 500 * time.Millisecond
+
+	snmpPath    = "/proc/net/snmp"
+	netstatPath = "/proc/net/netstat"
+)
+
+// ErrTCPStackUnavailable возвращается, если счётчики TCP-стека недоступны (не Linux).
+var ErrTCPStackUnavailable = errors.New("счётчики TCP-стека доступны только в Linux")
+
+// TCPStackCollector считает скорости счётчиков TCP и UDP ядра по приросту между вызовами.
+type TCPStackCollector struct {
+	mu     sync.Mutex
+	prev   map[string]uint64
+	prevAt time.Time
+}
+
+// NewTCPStackCollector создаёт сборщик счётчиков TCP-стека.
+func NewTCPStackCollector() *TCPStackCollector {
+	return &TCPStackCollector{}
+}
+
+// Collect возвращает скорости счётчиков TCP и UDP с предыдущего вызова. При первом вызове
+// счётчики снимаются дважды с интервалом tcpStackPrimeInterval.
+func (c *TCPStackCollector) Collect() (models.TCPStackStats, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.prev == nil {
+		counters, err := readTCPStackCounters()
+		if err != nil {
+			return models.TCPStackStats{}, err
+		}
+		c.prev, c.prevAt = counters, time.Now()
+		time.Sleep(tcpStackPrimeInterval)
+	}
+
+	counters, err := readTCPStackCounters()
+	if err != nil {
+		return models.TCPStackStats{}, err
+	}
+	now := time.Now()
+	elapsed := now.Sub(c.prevAt).Seconds()
+
+	rate := func(name string) float64 {
+		if elapsed <= 0 {
+			return 0
+		}
+		return counterRate(c.prev[name], counters[name], elapsed)
+	}
+
+	stats := models.TCPStackStats{
+		TCP: models.TCPCounters{
+			ActiveOpensPerSec:      rate("Tcp.ActiveOpens"),
+			PassiveOpensPerSec:     rate("Tcp.PassiveOpens"),
+			AttemptFailsPerSec:     rate("Tcp.AttemptFails"),
+			EstabResetsPerSec:      rate("Tcp.EstabResets"),
+			OutRstsPerSec:          rate("Tcp.OutRsts"),
+			InSegsPerSec:           rate("Tcp.InSegs"),
+			OutSegsPerSec:          rate("Tcp.OutSegs"),
+			RetransSegsPerSec:      rate("Tcp.RetransSegs"),
+			InErrsPerSec:           rate("Tcp.InErrs"),
+			ListenOverflowsPerSec:  rate("TcpExt.ListenOverflows"),
+			ListenDropsPerSec:      rate("TcpExt.ListenDrops"),
+			SyncookiesSentPerSec:   rate("TcpExt.SyncookiesSent"),
+			SyncookiesRecvPerSec:   rate("TcpExt.SyncookiesRecv"),
+			SyncookiesFailedPerSec: rate("TcpExt.SyncookiesFailed"),
+			CurrEstab:              counters["Tcp.CurrEstab"],
+		},
+		UDP: models.UDPCounters{
+			InDatagramsPerSec:  rate("Udp.InDatagrams"),
+			OutDatagramsPerSec: rate("Udp.OutDatagrams"),
+			NoPortsPerSec:      rate("Udp.NoPorts"),
+			InErrorsPerSec:     rate("Udp.InErrors"),
+			RcvbufErrorsPerSec: rate("Udp.RcvbufErrors"),
+			SndbufErrorsPerSec: rate("Udp.SndbufErrors"),
+		},
+	}
+	// OutSegs не включает повторные отправки, поэтому доля считается от их суммы.
+	if sent := stats.TCP.OutSegsPerSec + stats.TCP.RetransSegsPerSec; sent > 0 {
+		stats.TCP.RetransPercent = stats.TCP.RetransSegsPerSec / sent * 100
+	}
+
+	c.prev, c.prevAt = counters, now
+	return stats, nil
+}
+
+// readTCPStackCounters читает счётчики из /proc/net/snmp и /proc/net/netstat с ключами вида
+// "Tcp.RetransSegs" и "TcpExt.ListenOverflows". /proc/net/netstat необязателен: без него
+// счётчики TcpExt остаются нулевыми.
+func readTCPStackCounters() (map[string]uint64, error) {
+	counters := make(map[string]uint64)
+	if err := readProtocolCounters(snmpPath, counters); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrTCPStackUnavailable
+		}
+		return nil, err
+	}
+	if err := readProtocolCounters(netstatPath, counters); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return counters, nil
+}
+
+// readProtocolCounters разбирает файл формата /proc/net/snmp, где за строкой имён
+// "Tcp: ActiveOpens PassiveOpens ..." следует строка значений "Tcp: 19 19 ...".
+// Отрицательные значения (MaxConn = -1 - без ограничения) не являются счётчиками и пропускаются.
+func readProtocolCounters(path string, counters map[string]uint64) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		header := scanner.Text()
+		if !scanner.Scan() {
+			break
+		}
+		values := scanner.Text()
+
+		prefix, names, ok := strings.Cut(header, ":")
+		valuePrefix, numbers, valueOk := strings.Cut(values, ":")
+		if !ok || !valueOk || prefix != valuePrefix {
+			return fmt.Errorf("некорректный формат %s: строка %q", path, header)
+		}
+
+		nameFields, valueFields := strings.Fields(names), strings.Fields(numbers)
+		for i := 0; i < len(nameFields) && i < len(valueFields); i++ {
+			value, err := strconv.ParseUint(valueFields[i], 10, 64)
+			if err != nil {
+				continue
+			}
+			counters[prefix+"."+nameFields[i]] = value
+		}
+	}
+	return scanner.Err()
+}
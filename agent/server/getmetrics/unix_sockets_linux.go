@@ -0,0 +1,144 @@
+//go:build linux
+
+package getmetrics
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// Флаг и состояния UNIX-сокета в /proc/net/unix.
+const (
+	unixFlagAcceptCon    = 0x10000 // __SO_ACCEPTCON: сокет вызвал listen()
+	unixStateUnconnected = 1       // SS_UNCONNECTED
+	unixStateConnected   = 3       // SS_CONNECTED: сокет связан с другой стороной
+)
+
+// unixSocket - именованный UNIX-сокет из /proc/net/unix.
+type unixSocket struct {
+	path     string
+	sockType uint32
+	status   string
+	state    string
+}
+
+// listUnixSockets возвращает именованные UNIX-сокеты процессов.
+//
+// gopsutil здесь не подходит: он не отдаёт флаги и состояние сокета и схлопывает сокеты с одинаковым
+// путём, а у слушающего сокета и принятых им соединений путь один. Поэтому /proc/net/unix
+// разбирается напрямую, а владельцы сокетов находятся по ссылкам /proc/<pid>/fd/* вида "socket:[inode]".
+// Сокет, унаследованный несколькими процессами, попадает в список у каждого из них.
+func listUnixSockets() ([]models.ListeningPort, error) {
+	sockets, err := readUnixSockets()
+	if err != nil {
+		return nil, err
+	}
+
+	pids, err := process.Pids()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []models.ListeningPort{}
+	for _, pid := range pids {
+		seen := make(map[uint64]bool)
+		for _, inode := range processSocketInodes(pid) {
+			socket, ok := sockets[inode]
+			if !ok || seen[inode] {
+				continue
+			}
+			seen[inode] = true
+			result = append(result, newUnixSocketPort(pid, socket.path, socket.sockType, socket.status, socket.state))
+		}
+	}
+	return result, nil
+}
+
+// readUnixSockets разбирает /proc/net/unix и возвращает именованные сокеты по номеру inode.
+// Строки имеют вид "Num RefCount Protocol Flags Type St Inode [Path]", числа кроме Inode - шестнадцатеричные.
+func readUnixSockets() (map[uint64]unixSocket, error) {
+	data, err := os.ReadFile("/proc/net/unix")
+	if err != nil {
+		return nil, err
+	}
+
+	sockets := make(map[uint64]unixSocket)
+	lines := strings.Split(string(data), "\n")
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		// Анонимные сокеты (socketpair, клиентская сторона соединения) не имеют пути.
+		if len(fields) < 8 {
+			continue
+		}
+
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil {
+			continue
+		}
+		sockType, err := strconv.ParseUint(fields[4], 16, 32)
+		if err != nil {
+			continue
+		}
+		st, err := strconv.ParseUint(fields[5], 16, 8)
+		if err != nil {
+			continue
+		}
+		inode, err := strconv.ParseUint(fields[6], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		status, state := "NONE", SocketStateOther
+		switch {
+		case flags&unixFlagAcceptCon != 0:
+			status, state = "LISTEN", SocketStateListen
+		case st == unixStateConnected:
+			status, state = "CONNECTED", SocketStateEstablished
+		case st == unixStateUnconnected && sockType == sockDgram:
+			// Несвязанный датаграммный сокет с именем принимает датаграммы от любых отправителей.
+			status, state = "UNCONNECTED", SocketStateListen
+		case st == unixStateUnconnected:
+			status = "UNCONNECTED"
+		}
+
+		sockets[inode] = unixSocket{
+			// Путь может содержать пробелы, поэтому он берётся как остаток строки после inode.
+			path:     strings.Join(fields[7:], " "),
+			sockType: uint32(sockType),
+			status:   status,
+			state:    state,
+		}
+	}
+	return sockets, nil
+}
+
+// processSocketInodes возвращает inode сокетов, открытых процессом, по ссылкам /proc/<pid>/fd/*.
+// Если дескрипторы недоступны (нет прав, процесс завершился), возвращается пустой список.
+func processSocketInodes(pid int32) []uint64 {
+	dir := fmt.Sprintf("/proc/%d/fd", pid)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var inodes []uint64
+	for _, entry := range entries {
+		link, err := os.Readlink(dir + "/" + entry.Name())
+		if err != nil {
+			continue
+		}
+		value, ok := strings.CutPrefix(link, "socket:[")
+		if !ok {
+			continue
+		}
+		if inode, err := strconv.ParseUint(strings.TrimSuffix(value, "]"), 10, 64); err == nil {
+			inodes = append(inodes, inode)
+		}
+	}
+	return inodes
+}
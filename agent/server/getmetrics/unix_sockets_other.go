@@ -0,0 +1,26 @@
+//go:build !linux
+
+package getmetrics
+
+import (
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+// listUnixSockets возвращает именованные UNIX-сокеты процессов через gopsutil.
+// Вне Linux состояние UNIX-сокетов неизвестно, поэтому у всех них состояние other.
+func listUnixSockets() ([]models.ListeningPort, error) {
+	conns, err := net.Connections("unix")
+	if err != nil {
+		return nil, err
+	}
+
+	result := []models.ListeningPort{}
+	for _, conn := range conns {
+		if conn.Laddr.IP == "" {
+			continue
+		}
+		result = append(result, newUnixSocketPort(conn.Pid, conn.Laddr.IP, conn.Type, conn.Status, SocketStateOther))
+	}
+	return result, nil
+}
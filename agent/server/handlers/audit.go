@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/RZhurakovskiy/agent/server/db"
+	"github.com/RZhurakovskiy/agent/server/models"
+)
+
+// maxAuditedBodySize ограничивает размер тела запроса, сохраняемого в журнал аудита.
+const maxAuditedBodySize = 64 * 1024
+
+// GetAudit возвращает страницу журнала аудита с фильтрами action, pid, outcome, client,
+// from, to и постраничным выводом limit/offset.
+func GetAudit(sqlDB *sql.DB) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodGet {
+			http.Error(writer, "Метод не разрешён. Используйте GET", http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := request.URL.Query()
+
+		pid, err := parseIntParam(query, "pid", 0)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		limit, err := parseIntParam(query, "limit", 100)
+		if err != nil || limit == 0 || limit > 1000 {
+			http.Error(writer, "Параметр limit должен быть от 1 до 1000", http.StatusBadRequest)
+			return
+		}
+		offset, err := parseIntParam(query, "offset", 0)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		from, err := parseTimeParam(query, "from")
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		to, err := parseTimeParam(query, "to")
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		entries, total, err := db.QueryAuditEntries(sqlDB, db.AuditFilter{
+			Action:  query.Get("action"),
+			PID:     int32(pid),
+			Outcome: query.Get("outcome"),
+			Client:  query.Get("client"),
+			From:    from,
+			To:      to,
+			Limit:   limit,
+			Offset:  offset,
+		})
+		if err != nil {
+			log.Printf("Ошибка получения журнала аудита: %v", err)
+			http.Error(writer, "Ошибка получения журнала аудита", http.StatusInternalServerError)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(writer).Encode(models.AuditPage{
+			Total:   total,
+			Limit:   limit,
+			Offset:  offset,
+			Entries: entries,
+		}); err != nil {
+			log.Printf("Ошибка сериализации ответа в GetAudit: %v", err)
+		}
+	}
+}
+
+// clientAddr возвращает адрес клиента для журнала аудита. Если запрос прошёл через прокси,
+// к адресу соединения добавляется X-Forwarded-For.
+func clientAddr(request *http.Request) string {
+	if forwarded := request.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return fmt.Sprintf("%s (через %s)", forwarded, request.RemoteAddr)
+	}
+	return request.RemoteAddr
+}
+
+// readAuditedBody читает тело запроса целиком, чтобы сохранить его в журнал аудита.
+func readAuditedBody(request *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(request.Body, maxAuditedBodySize+1))
+	if err != nil {
+		return body, err
+	}
+	if len(body) > maxAuditedBodySize {
+		return body[:maxAuditedBodySize], fmt.Errorf("тело запроса больше %d байт", maxAuditedBodySize)
+	}
+	return body, nil
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/RZhurakovskiy/agent/server/getmetrics"
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/RZhurakovskiy/agent/server/ws"
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+// cgroupCollector считает CPU групп по приросту cpu.stat между запросами.
+var cgroupCollector = getmetrics.NewCgroupCollector()
+
+// GetCgroups возвращает сводку CPU и памяти по cgroup, в которых есть процессы.
+// Параметр container оставляет только группы контейнера (ID или префикс ID, "none" - вне контейнеров).
+// При включённом мониторинге используется снимок из кэша /ws/processes, иначе процессы снимаются на месте.
+func GetCgroups(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		http.Error(writer, "Метод не разрешён. Используйте GET", http.StatusMethodNotAllowed)
+		return
+	}
+
+	procs, _, ok := ws.ProcessesSnapshot()
+	if !ok {
+		var err error
+		procs, err = collectProcesses([]net.ConnectionStat{})
+		if err != nil {
+			log.Printf("Ошибка получения списка процессов: %v", err)
+			http.Error(writer, "Ошибка получения списка процессов", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	container := request.URL.Query().Get("container")
+	groups := cgroupCollector.Collect(procs)
+	result := make([]models.CgroupStats, 0, len(groups))
+	for _, group := range groups {
+		if getmetrics.MatchContainer(group.ContainerID, container) {
+			result = append(result, group)
+		}
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(writer).Encode(result); err != nil {
+		log.Printf("Ошибка сериализации ответа в GetCgroups: %v", err)
+	}
+}
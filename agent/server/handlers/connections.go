@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/getmetrics"
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/RZhurakovskiy/agent/server/ws"
+)
+
+// GetConnections возвращает TCP- и UDP-соединения процессов, включая переходные состояния TCP.
+// Фильтры: pid, protocol, state, localPort, remotePort, remote (IP-адрес или подсеть CIDR).
+// При включённом мониторинге используется снимок из кэша /ws/connections, иначе сокеты снимаются на месте.
+func GetConnections(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		http.Error(writer, "Метод не разрешён. Используйте GET", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := getmetrics.ParseConnectionFilter(request.URL.Query())
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	conns, _, err := connectionsSnapshot()
+	if err != nil {
+		log.Printf("Ошибка получения сетевых соединений: %v", err)
+		http.Error(writer, "Ошибка получения сетевых соединений", http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(writer).Encode(getmetrics.FilterConnections(conns, filter)); err != nil {
+		log.Printf("Ошибка сериализации ответа в GetConnections: %v", err)
+	}
+}
+
+// GetConnectionsSummary возвращает число соединений по состояниям, удалённым адресам и процессам.
+// Принимает те же фильтры, что и GetConnections; limit ограничивает строки по адресам и процессам.
+func GetConnectionsSummary(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		http.Error(writer, "Метод не разрешён. Используйте GET", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := request.URL.Query()
+	filter, err := getmetrics.ParseConnectionFilter(query)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, err := parseIntParam(query, "limit", getmetrics.DefaultConnectionsSummaryLimit)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	conns, timestamp, err := connectionsSnapshot()
+	if err != nil {
+		log.Printf("Ошибка получения сетевых соединений: %v", err)
+		http.Error(writer, "Ошибка получения сетевых соединений", http.StatusInternalServerError)
+		return
+	}
+
+	summary := getmetrics.SummarizeConnections(getmetrics.FilterConnections(conns, filter), limit)
+	summary.Timestamp = timestamp
+
+	writer.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(writer).Encode(summary); err != nil {
+		log.Printf("Ошибка сериализации ответа в GetConnectionsSummary: %v", err)
+	}
+}
+
+// connectionsSnapshot возвращает соединения из кэша мониторинга или, если он выключен, снимает их на месте.
+func connectionsSnapshot() ([]models.Connection, string, error) {
+	if conns, timestamp, ok := ws.ConnectionsSnapshot(); ok {
+		return conns, timestamp, nil
+	}
+
+	conns, err := getmetrics.CollectConnections(make(map[int32]string))
+	if err != nil {
+		return nil, "", err
+	}
+	return conns, time.Now().Format("2006-01-02 15:04:05"), nil
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/getmetrics"
+	"github.com/RZhurakovskiy/agent/server/ws"
+)
+
+// diskCollector снимает метрики дисков по запросу, когда мониторинг выключен.
+// Скорости ввода-вывода в этом случае считаются с предыдущего запроса.
+var diskCollector = getmetrics.NewDiskCollector()
+
+// GetDisk возвращает заполненность точек монтирования и ввод-вывод устройств.
+// При включённом мониторинге отдаётся снимок из кэша /ws/disk, иначе данные снимаются на месте.
+func GetDisk(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		http.Error(writer, "Метод не разрешён. Используйте GET", http.StatusMethodNotAllowed)
+		return
+	}
+
+	stats, ok := ws.DiskSnapshot()
+	if !ok {
+		var err error
+		stats, err = diskCollector.Collect()
+		if err != nil {
+			log.Printf("Ошибка получения метрик дисков: %v", err)
+			http.Error(writer, "Ошибка получения метрик дисков", http.StatusInternalServerError)
+			return
+		}
+		stats.Timestamp = time.Now().Format("2006-01-02 15:04:05")
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(writer).Encode(stats); err != nil {
+		log.Printf("Ошибка сериализации ответа в GetDisk: %v", err)
+	}
+}
@@ -2,21 +2,37 @@ package handlers
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
 
 	"github.com/RZhurakovskiy/agent/server/getmetrics"
 )
 
+// GetListeningPort возвращает TCP-, UDP- и UNIX-сокеты процессов.
+// Параметры protocol (tcp, tcp6, udp, udp6, unix) и state (listen, established, other)
+// принимают несколько значений через запятую.
 func GetListeningPort(writer http.ResponseWriter, request *http.Request) {
 	if request.Method != http.MethodGet {
 		http.Error(writer, "Метод не разрешен. Разрешён только GET", http.StatusMethodNotAllowed)
 		return
 	}
 
-	result, err := getmetrics.GetListeningPorts()
+	query := request.URL.Query()
+	protocols, err := getmetrics.ParseListParam(query, "protocol", getmetrics.ListeningPortProtocols)
 	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	states, err := getmetrics.ParseListParam(query, "state", getmetrics.SocketStates)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-		http.Error(writer, "Ошибка получения информации о портах:", http.StatusInternalServerError)
+	result, err := getmetrics.GetListeningPorts(getmetrics.ListeningPortsFilter{Protocols: protocols, States: states})
+	if err != nil {
+		log.Printf("Ошибка получения информации о портах: %v", err)
+		http.Error(writer, "Ошибка получения информации о портах", http.StatusInternalServerError)
 		return
 	}
 
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/RZhurakovskiy/agent/server/services"
+)
+
+// metricNamePattern ограничивает имена метрик в пути запроса.
+var metricNamePattern = regexp.MustCompile(`^[a-z0-9_.]+$`)
+
+// GetHistory возвращает историю метрики {metric} за интервал from-to (по умолчанию - последний час).
+// Шаг step задаётся в секундах или как длительность ("5m", "1h"); без него подбирается автоматически.
+func GetHistory(recorder *services.HistoryRecorder) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodGet {
+			http.Error(writer, "Метод не разрешён. Используйте GET", http.StatusMethodNotAllowed)
+			return
+		}
+
+		metric := request.PathValue("metric")
+		if !metricNamePattern.MatchString(metric) {
+			http.Error(writer, "Некорректное имя метрики", http.StatusBadRequest)
+			return
+		}
+
+		query := request.URL.Query()
+		from, err := parseTimeParam(query, "from")
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		to, err := parseTimeParam(query, "to")
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		step, err := parseStepParam(query.Get("step"))
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if to.IsZero() {
+			to = time.Now()
+		}
+		if from.IsZero() {
+			from = to.Add(-time.Hour)
+		}
+
+		history, err := recorder.Query(metric, from, to, step)
+		if errors.Is(err, services.ErrInvalidHistoryRange) {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Printf("Ошибка получения истории %s: %v", metric, err)
+			http.Error(writer, "Ошибка получения истории метрики", http.StatusInternalServerError)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(writer).Encode(history); err != nil {
+			log.Printf("Ошибка сериализации ответа в GetHistory: %v", err)
+		}
+	}
+}
+
+// HistoryConfig возвращает (GET) или меняет (POST) сроки хранения истории метрик.
+func HistoryConfig(recorder *services.HistoryRecorder) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		switch request.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			var input models.HistoryConfig
+			if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+				log.Printf("Ошибка декодирования JSON в HistoryConfig: %v", err)
+				http.Error(writer, "Некорректный JSON. Ожидается: {\"rawRetentionHours\": <число>, \"minuteRetentionDays\": <число>, \"hourRetentionDays\": <число>}", http.StatusBadRequest)
+				return
+			}
+			if err := recorder.SetConfig(input); err != nil {
+				http.Error(writer, err.Error(), http.StatusBadRequest)
+				return
+			}
+			log.Printf("Сроки хранения истории изменены через API: %+v", input)
+		default:
+			http.Error(writer, "Метод не разрешён. Используйте GET или POST", http.StatusMethodNotAllowed)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(writer).Encode(recorder.Config()); err != nil {
+			log.Printf("Ошибка сериализации ответа в HistoryConfig: %v", err)
+		}
+	}
+}
+
+// parseStepParam разбирает шаг истории: число секунд или длительность Go.
+func parseStepParam(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	if step, err := time.ParseDuration(value); err == nil && step > 0 {
+		return step, nil
+	}
+	return 0, fmt.Errorf("некорректное значение параметра step: %q", value)
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/RZhurakovskiy/agent/server/db"
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/RZhurakovskiy/agent/server/services"
+)
+
+// integrityRuleRequest - тело запроса на добавление правила. Вместо value можно передать pid:
+// тогда в правило попадёт хэш или путь исполняемого файла этого процесса.
+type integrityRuleRequest struct {
+	List    string `json:"list"`
+	Kind    string `json:"kind"`
+	Value   string `json:"value"`
+	Comment string `json:"comment"`
+	PID     int32  `json:"pid"`
+}
+
+// IntegrityRules возвращает правила списков разрешённых и запрещённых исполняемых файлов (GET)
+// или добавляет правило (POST). Добавление записывается в журнал аудита.
+func IntegrityRules(checker *services.IntegrityChecker, audit *services.AuditLog) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		switch request.Method {
+		case http.MethodGet:
+			listIntegrityRules(writer, checker)
+		case http.MethodPost:
+			addIntegrityRule(writer, request, checker, audit)
+		default:
+			http.Error(writer, "Метод не разрешён. Используйте GET или POST", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func listIntegrityRules(writer http.ResponseWriter, checker *services.IntegrityChecker) {
+	rules, err := checker.Rules()
+	if err != nil {
+		log.Printf("Ошибка получения правил целостности: %v", err)
+		http.Error(writer, "Ошибка получения правил целостности", http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(writer).Encode(rules); err != nil {
+		log.Printf("Ошибка сериализации ответа в IntegrityRules: %v", err)
+	}
+}
+
+func addIntegrityRule(writer http.ResponseWriter, request *http.Request, checker *services.IntegrityChecker, audit *services.AuditLog) {
+	entry := models.AuditEntry{
+		Action:     models.AuditActionIntegrityRuleAdd,
+		ClientAddr: clientAddr(request),
+	}
+
+	body, err := readAuditedBody(request)
+	entry.Params = string(body)
+	if err != nil {
+		log.Printf("Ошибка чтения запроса в IntegrityRules: %v", err)
+		entry.Outcome, entry.Error = models.AuditOutcomeRejected, err.Error()
+		audit.Record(entry)
+		http.Error(writer, "Не удалось прочитать запрос", http.StatusBadRequest)
+		return
+	}
+
+	var input integrityRuleRequest
+	if err := json.Unmarshal(body, &input); err != nil {
+		log.Printf("Ошибка декодирования JSON в IntegrityRules: %v", err)
+		entry.Outcome, entry.Error = models.AuditOutcomeRejected, err.Error()
+		audit.Record(entry)
+		http.Error(writer, "Некорректный JSON. Ожидается: {\"list\": \"allow\"|\"deny\", \"kind\": \"hash\"|\"path\", \"value\": \"...\"} или {\"list\": ..., \"kind\": ..., \"pid\": <число>}", http.StatusBadRequest)
+		return
+	}
+
+	rule := models.IntegrityRule{List: input.List, Kind: input.Kind, Value: input.Value, Comment: input.Comment}
+	if input.PID > 0 && input.Value == "" {
+		entry.PID = input.PID
+		hash, exe, err := checker.HashProcess(input.PID)
+		entry.TargetName = exe
+		switch {
+		case input.Kind == models.IntegrityKindPath && exe != "":
+			rule.Value = exe
+		case err != nil:
+			entry.Outcome, entry.Error = models.AuditOutcomeRejected, err.Error()
+			audit.Record(entry)
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		default:
+			rule.Value = hash
+		}
+	}
+
+	rule, err = checker.AddRule(rule)
+	if err != nil {
+		entry.Outcome, entry.Error = models.AuditOutcomeRejected, err.Error()
+		audit.Record(entry)
+		status := http.StatusBadRequest
+		if errors.Is(err, db.ErrIntegrityRuleExists) {
+			status = http.StatusConflict
+		}
+		http.Error(writer, err.Error(), status)
+		return
+	}
+
+	entry.Outcome = models.AuditOutcomeSuccess
+	audit.Record(entry)
+
+	writer.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(writer).Encode(rule); err != nil {
+		log.Printf("Ошибка сериализации ответа в IntegrityRules: %v", err)
+	}
+}
+
+// DeleteIntegrityRule удаляет правило {id} из списков и записывает удаление в журнал аудита.
+func DeleteIntegrityRule(checker *services.IntegrityChecker, audit *services.AuditLog) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodDelete {
+			http.Error(writer, "Метод не разрешён. Используйте DELETE", http.StatusMethodNotAllowed)
+			return
+		}
+
+		entry := models.AuditEntry{
+			Action:     models.AuditActionIntegrityRuleDelete,
+			Params:     request.PathValue("id"),
+			ClientAddr: clientAddr(request),
+		}
+
+		id, err := strconv.ParseInt(request.PathValue("id"), 10, 64)
+		if err != nil || id <= 0 {
+			entry.Outcome, entry.Error = models.AuditOutcomeRejected, "некорректный id правила"
+			audit.Record(entry)
+			http.Error(writer, "Некорректный id правила", http.StatusBadRequest)
+			return
+		}
+
+		if err := checker.DeleteRule(id); err != nil {
+			if errors.Is(err, db.ErrIntegrityRuleNotFound) {
+				entry.Outcome, entry.Error = models.AuditOutcomeRejected, err.Error()
+				audit.Record(entry)
+				http.Error(writer, err.Error(), http.StatusNotFound)
+				return
+			}
+			log.Printf("Ошибка удаления правила целостности %d: %v", id, err)
+			entry.Outcome, entry.Error = models.AuditOutcomeFailure, err.Error()
+			audit.Record(entry)
+			http.Error(writer, "Ошибка удаления правила целостности", http.StatusInternalServerError)
+			return
+		}
+
+		entry.Outcome = models.AuditOutcomeSuccess
+		audit.Record(entry)
+		writer.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetIntegrityEvents возвращает события о впервые замеченных неизвестных хэшах
+// с фильтрами from, to и limit.
+func GetIntegrityEvents(sqlDB *sql.DB) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodGet {
+			http.Error(writer, "Метод не разрешён. Используйте GET", http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := request.URL.Query()
+
+		limit, err := parseIntParam(query, "limit", 500)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		from, err := parseTimeParam(query, "from")
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		to, err := parseTimeParam(query, "to")
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		events, err := db.QueryIntegrityEvents(sqlDB, from, to, limit)
+		if err != nil {
+			log.Printf("Ошибка получения событий целостности: %v", err)
+			http.Error(writer, "Ошибка получения событий целостности", http.StatusInternalServerError)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(writer).Encode(events); err != nil {
+			log.Printf("Ошибка сериализации ответа в GetIntegrityEvents: %v", err)
+		}
+	}
+}
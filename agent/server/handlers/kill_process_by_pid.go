@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/RZhurakovskiy/agent/server/services"
 	"github.com/shirou/gopsutil/v4/process"
 )
 
@@ -24,67 +25,104 @@ func processExists(pid int32) (bool, error) {
 	return true, nil
 }
 
-func KillProcessById(writer http.ResponseWriter, request *http.Request) {
+// KillProcessById завершает процесс по PID и записывает попытку в журнал аудита.
+func KillProcessById(audit *services.AuditLog) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
 
-	if request.Method != http.MethodPost {
-		http.Error(writer, "Метод не разрешён. Используйте POST", http.StatusMethodNotAllowed)
-		return
-	}
+		if request.Method != http.MethodPost {
+			http.Error(writer, "Метод не разрешён. Используйте POST", http.StatusMethodNotAllowed)
+			return
+		}
 
-	var input struct {
-		PID int32 `json:"pid"`
-	}
+		entry := models.AuditEntry{
+			Action:     models.AuditActionKill,
+			ClientAddr: clientAddr(request),
+		}
 
-	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
-		log.Printf("Ошибка декодирования JSON в KillProcessById: %v", err)
-		http.Error(writer, "Некорректный JSON. Ожидается: {\"pid\": <число>}", http.StatusBadRequest)
-		return
-	}
+		body, err := readAuditedBody(request)
+		entry.Params = string(body)
+		if err != nil {
+			log.Printf("Ошибка чтения запроса в KillProcessById: %v", err)
+			entry.Outcome, entry.Error = models.AuditOutcomeRejected, err.Error()
+			audit.Record(entry)
+			http.Error(writer, "Не удалось прочитать запрос", http.StatusBadRequest)
+			return
+		}
 
-	pid := input.PID
+		var input struct {
+			PID int32 `json:"pid"`
+		}
 
-	if pid <= 0 {
-		http.Error(writer, "Некорректный PID. PID должен быть положительным числом", http.StatusBadRequest)
-		return
-	}
+		if err := json.Unmarshal(body, &input); err != nil {
+			log.Printf("Ошибка декодирования JSON в KillProcessById: %v", err)
+			entry.Outcome, entry.Error = models.AuditOutcomeRejected, err.Error()
+			audit.Record(entry)
+			http.Error(writer, "Некорректный JSON. Ожидается: {\"pid\": <число>}", http.StatusBadRequest)
+			return
+		}
 
-	var message string
+		pid := input.PID
+		entry.PID = pid
 
-	exists, err := processExists(pid)
-	if err != nil {
-		log.Printf("Ошибка проверки существования процесса %d: %v", pid, err)
-		message = "Ошибка проверки процесса: " + err.Error()
-	} else if !exists {
-		message = "Процесс не найден"
-	} else {
+		if pid <= 0 {
+			entry.Outcome, entry.Error = models.AuditOutcomeRejected, "некорректный PID"
+			audit.Record(entry)
+			http.Error(writer, "Некорректный PID. PID должен быть положительным числом", http.StatusBadRequest)
+			return
+		}
+
+		var message string
+		entry.Outcome = models.AuditOutcomeFailure
 
-		proc, err := process.NewProcess(pid)
+		exists, err := processExists(pid)
 		if err != nil {
-			log.Printf("Ошибка создания объекта процесса %d: %v", pid, err)
-			message = "Не удалось загрузить процесс: " + err.Error()
+			log.Printf("Ошибка проверки существования процесса %d: %v", pid, err)
+			message = "Ошибка проверки процесса: " + err.Error()
+		} else if !exists {
+			message = "Процесс не найден"
 		} else {
 
-			err = proc.Kill()
+			proc, err := process.NewProcess(pid)
 			if err != nil {
-				log.Printf("Ошибка завершения процесса %d: %v", pid, err)
-				message = "Не удалось завершить процесс: " + err.Error()
+				log.Printf("Ошибка создания объекта процесса %d: %v", pid, err)
+				message = "Не удалось загрузить процесс: " + err.Error()
 			} else {
-				log.Printf("Процесс %d успешно завершен", pid)
-				message = "Процесс успешно завершен"
+
+				if name, err := proc.Name(); err == nil {
+					entry.TargetName = name
+				}
+				if cmdline, err := proc.Cmdline(); err == nil {
+					entry.TargetCmdline = cmdline
+				}
+
+				err = proc.Kill()
+				if err != nil {
+					log.Printf("Ошибка завершения процесса %d: %v", pid, err)
+					message = "Не удалось завершить процесс: " + err.Error()
+				} else {
+					log.Printf("Процесс %d успешно завершен", pid)
+					message = "Процесс успешно завершен"
+					entry.Outcome = models.AuditOutcomeSuccess
+				}
 			}
 		}
-	}
 
-	response := models.KillProcessByID{
-		PID:       pid,
-		Message:   message,
-		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
-	}
+		if entry.Outcome != models.AuditOutcomeSuccess {
+			entry.Error = message
+		}
+		audit.Record(entry)
 
-	writer.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(writer).Encode(response); err != nil {
-		log.Printf("Ошибка сериализации ответа в KillProcessById: %v", err)
-		http.Error(writer, "Ошибка формирования ответа", http.StatusInternalServerError)
-		return
+		response := models.KillProcessByID{
+			PID:       pid,
+			Message:   message,
+			Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+		}
+
+		writer.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(writer).Encode(response); err != nil {
+			log.Printf("Ошибка сериализации ответа в KillProcessById: %v", err)
+			http.Error(writer, "Ошибка формирования ответа", http.StatusInternalServerError)
+			return
+		}
 	}
 }
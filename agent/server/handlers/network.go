@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/getmetrics"
+	"github.com/RZhurakovskiy/agent/server/ws"
+)
+
+// networkCollector снимает трафик интерфейсов по запросу, когда мониторинг выключен.
+// Скорости в этом случае считаются с предыдущего запроса.
+var networkCollector = getmetrics.NewNetworkCollector()
+
+// GetNetwork возвращает трафик сетевых интерфейсов: байты, пакеты, ошибки и отброшенные пакеты в секунду.
+// При включённом мониторинге отдаётся снимок из кэша /ws/network, иначе данные снимаются на месте.
+func GetNetwork(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		http.Error(writer, "Метод не разрешён. Используйте GET", http.StatusMethodNotAllowed)
+		return
+	}
+
+	stats, ok := ws.NetworkSnapshot()
+	if !ok {
+		var err error
+		stats, err = networkCollector.Collect()
+		if err != nil {
+			log.Printf("Ошибка получения метрик сети: %v", err)
+			http.Error(writer, "Ошибка получения метрик сети", http.StatusInternalServerError)
+			return
+		}
+		stats.Timestamp = time.Now().Format("2006-01-02 15:04:05")
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(writer).Encode(stats); err != nil {
+		log.Printf("Ошибка сериализации ответа в GetNetwork: %v", err)
+	}
+}
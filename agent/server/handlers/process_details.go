@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/RZhurakovskiy/agent/server/getmetrics"
+)
+
+// GetProcessDetails возвращает подробную информацию о процессе {pid}: рабочий каталог,
+// окружение (со скрытыми секретами), открытые файлы, соединения, потоки, лимиты, cgroup и потомков.
+func GetProcessDetails(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		http.Error(writer, "Метод не разрешён. Используйте GET", http.StatusMethodNotAllowed)
+		return
+	}
+
+	pid, err := strconv.ParseInt(request.PathValue("pid"), 10, 32)
+	if err != nil || pid <= 0 {
+		http.Error(writer, "Некорректный PID. PID должен быть положительным числом", http.StatusBadRequest)
+		return
+	}
+
+	details, err := getmetrics.ProcessDetails(int32(pid))
+	if errors.Is(err, getmetrics.ErrProcessNotFound) {
+		http.Error(writer, "Процесс не найден", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Ошибка получения информации о процессе %d: %v", pid, err)
+		http.Error(writer, "Ошибка получения информации о процессе", http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(writer).Encode(details); err != nil {
+		log.Printf("Ошибка сериализации ответа в GetProcessDetails: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/RZhurakovskiy/agent/server/db"
+	"github.com/RZhurakovskiy/agent/server/models"
+)
+
+// GetProcessEvents возвращает события запуска и завершения процессов
+// с фильтрами pid, name (подстрока), type, from, to и limit.
+func GetProcessEvents(sqlDB *sql.DB) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodGet {
+			http.Error(writer, "Метод не разрешён. Используйте GET", http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := request.URL.Query()
+
+		eventType := query.Get("type")
+		if eventType != "" && eventType != models.ProcessEventStarted && eventType != models.ProcessEventExited {
+			http.Error(writer, "Параметр type должен быть \"started\" или \"exited\"", http.StatusBadRequest)
+			return
+		}
+
+		pid, err := parseIntParam(query, "pid", 0)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		limit, err := parseIntParam(query, "limit", 500)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		from, err := parseTimeParam(query, "from")
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		to, err := parseTimeParam(query, "to")
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		events, err := db.QueryProcessEvents(sqlDB, db.ProcessEventFilter{
+			PID:   int32(pid),
+			Name:  query.Get("name"),
+			Type:  eventType,
+			From:  from,
+			To:    to,
+			Limit: limit,
+		})
+		if err != nil {
+			log.Printf("Ошибка получения событий процессов: %v", err)
+			http.Error(writer, "Ошибка получения событий процессов", http.StatusInternalServerError)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(writer).Encode(events); err != nil {
+			log.Printf("Ошибка сериализации ответа в GetProcessEvents: %v", err)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/getmetrics"
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/RZhurakovskiy/agent/server/ws"
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+// processSampleInterval - интервал, за который считается CPU% процессов, снятых по запросу.
+const processSampleInterval = time.Second
+
+// collectProcesses снимает процессы по запросу, когда мониторинг выключен. Каждый запрос снимает
+// процессы дважды с интервалом processSampleInterval, поэтому CPU% не зависит от предыдущих запросов.
+func collectProcesses(allConnections []net.ConnectionStat) ([]models.ProcessInfo, error) {
+	return getmetrics.SampleProcesses(allConnections, processSampleInterval)
+}
+
+// GetProcessTree возвращает дерево процессов с суммарными CPU и RSS поддеревьев.
+// Параметр root ограничивает ответ поддеревом процесса с указанным PID.
+// При включённом мониторинге используется снимок из кэша /ws/processes, иначе процессы снимаются на месте.
+func GetProcessTree(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		http.Error(writer, "Метод не разрешён. Используйте GET", http.StatusMethodNotAllowed)
+		return
+	}
+
+	rootPID, err := parseIntParam(request.URL.Query(), "root", 0)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	procs, _, ok := ws.ProcessesSnapshot()
+	if !ok {
+		procs, err = collectProcesses([]net.ConnectionStat{})
+		if err != nil {
+			log.Printf("Ошибка получения списка процессов: %v", err)
+			http.Error(writer, "Ошибка получения списка процессов", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	tree := getmetrics.BuildProcessTree(procs)
+	if rootPID > 0 {
+		node := getmetrics.FindProcessNode(tree, int32(rootPID))
+		if node == nil {
+			http.Error(writer, "Процесс не найден", http.StatusNotFound)
+			return
+		}
+		tree = []*models.ProcessNode{node}
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(writer).Encode(tree); err != nil {
+		log.Printf("Ошибка сериализации ответа в GetProcessTree: %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/getmetrics"
+	"github.com/RZhurakovskiy/agent/server/ws"
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+// GetProcesses возвращает окно отфильтрованного и отсортированного списка процессов.
+// Фильтры: pid, name, nameRegex, user, port, status, cpuMin/cpuMax, memoryMin/memoryMax (RSS в МБ);
+// сортировка: sort и order; окно: limit и offset (см. getmetrics.ParseProcessQuery).
+// При включённом мониторинге используется снимок из кэша /ws/processes, иначе процессы снимаются на месте
+// (дважды за секунду, чтобы посчитать CPU%); сокеты для портов при этом снимаются только для фильтра port.
+func GetProcesses(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		http.Error(writer, "Метод не разрешён. Используйте GET", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query, err := getmetrics.ParseProcessQuery(request.URL.Query())
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	procs, timestamp, ok := ws.ProcessesSnapshot()
+	if !ok {
+		connections := []net.ConnectionStat{}
+		if query.Port != 0 {
+			if connections, err = net.Connections("all"); err != nil {
+				log.Printf("Ошибка получения сетевых соединений: %v", err)
+				http.Error(writer, "Ошибка получения сетевых соединений", http.StatusInternalServerError)
+				return
+			}
+		}
+		procs, err = collectProcesses(connections)
+		if err != nil {
+			log.Printf("Ошибка получения списка процессов: %v", err)
+			http.Error(writer, "Ошибка получения списка процессов", http.StatusInternalServerError)
+			return
+		}
+		timestamp = time.Now().Format("2006-01-02 15:04:05")
+	}
+
+	page := getmetrics.QueryProcesses(procs, query)
+	page.Timestamp = timestamp
+
+	writer.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(writer).Encode(page); err != nil {
+		log.Printf("Ошибка сериализации ответа в GetProcesses: %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// parseTimeParam разбирает время из query-параметра. Поддерживаются Unix-время в секундах,
+// RFC 3339 и формат "2006-01-02 15:04:05" (в локальном часовом поясе).
+// Пустой параметр даёт нулевое время.
+func parseTimeParam(query url.Values, name string) (time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(unix, 0), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", value, time.Local); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("некорректное значение параметра %s: %q", name, value)
+}
+
+// parseIntParam разбирает целое число из query-параметра. Пустой параметр даёт defaultValue.
+func parseIntParam(query url.Values, name string, defaultValue int) (int, error) {
+	value := query.Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("некорректное значение параметра %s: %q", name, value)
+	}
+	return n, nil
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/RZhurakovskiy/agent/server/getmetrics"
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/RZhurakovskiy/agent/server/services"
+	"github.com/RZhurakovskiy/agent/server/ws"
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+// securityScanner проверяет процессы по запросу, когда мониторинг выключен.
+// Запрос дольше на секунду, если предыдущая проверка была больше 5 секунд назад: процессы снимаются
+// дважды, чтобы посчитать CPU.
+var securityScanner = getmetrics.NewSecurityScanner()
+
+// GetSecurityFindings возвращает подозрительные процессы с уровнем серьёзности и объяснением каждой находки.
+// Параметр severity (low, medium, high, critical) оставляет находки не ниже указанного уровня.
+// При включённом мониторинге проверяется снимок процессов из кэша /ws/processes.
+// Процессы с исполняемым файлом из списка запрещённых checker попадают в отчёт с находкой denied-exe.
+func GetSecurityFindings(checker *services.IntegrityChecker) http.HandlerFunc {
+	if checker != nil {
+		securityScanner.SetVerifier(checker)
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodGet {
+			http.Error(writer, "Метод не разрешён. Используйте GET", http.StatusMethodNotAllowed)
+			return
+		}
+
+		severity := request.URL.Query().Get("severity")
+		if severity != "" && getmetrics.SeverityRank(severity) == 0 {
+			http.Error(writer, fmt.Sprintf("некорректное значение параметра severity: %q, допустимо: low, medium, high, critical", severity), http.StatusBadRequest)
+			return
+		}
+
+		var report models.SecurityReport
+		if procs, _, ok := ws.ProcessesSnapshot(); ok {
+			conns, err := net.Connections("inet")
+			if err != nil {
+				log.Printf("Ошибка получения сетевых соединений: %v", err)
+				http.Error(writer, "Ошибка получения сетевых соединений", http.StatusInternalServerError)
+				return
+			}
+			report = getmetrics.DetectSuspiciousProcesses(procs, conns)
+			if checker != nil {
+				report.PendingHashes = getmetrics.PendingHashes(procs)
+			}
+		} else {
+			var err error
+			report, err = securityScanner.Scan()
+			if err != nil {
+				log.Printf("Ошибка проверки подозрительных процессов: %v", err)
+				http.Error(writer, "Ошибка проверки подозрительных процессов", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		if severity != "" {
+			report.Findings = getmetrics.FilterFindings(report.Findings, severity)
+		}
+
+		writer.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(writer).Encode(report); err != nil {
+			log.Printf("Ошибка сериализации ответа в GetSecurityFindings: %v", err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/RZhurakovskiy/agent/server/db"
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/RZhurakovskiy/agent/server/services"
+)
+
+// Sessions возвращает список сессий записи (GET) или запускает/останавливает сессию (POST).
+func Sessions(sqlDB *sql.DB, recorder *services.SessionRecorder) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		switch request.Method {
+		case http.MethodGet:
+			listSessions(writer, sqlDB)
+		case http.MethodPost:
+			controlSession(writer, request, recorder)
+		default:
+			http.Error(writer, "Метод не разрешён. Используйте GET или POST", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func listSessions(writer http.ResponseWriter, sqlDB *sql.DB) {
+	sessions, err := db.ListSessions(sqlDB)
+	if err != nil {
+		log.Printf("Ошибка получения сессий: %v", err)
+		http.Error(writer, "Ошибка получения сессий", http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(writer).Encode(sessions); err != nil {
+		log.Printf("Ошибка сериализации ответа в Sessions: %v", err)
+	}
+}
+
+func controlSession(writer http.ResponseWriter, request *http.Request, recorder *services.SessionRecorder) {
+	var input models.SessionRequest
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		log.Printf("Ошибка декодирования JSON в Sessions: %v", err)
+		http.Error(writer, "Некорректный JSON. Ожидается: {\"action\": \"start\"|\"stop\", ...}", http.StatusBadRequest)
+		return
+	}
+
+	var session models.Session
+	var err error
+
+	switch input.Action {
+	case "start":
+		session, err = recorder.Start(input.Name, input.CPUThreshold, input.IntervalSec)
+		if errors.Is(err, services.ErrSessionActive) {
+			http.Error(writer, err.Error(), http.StatusConflict)
+			return
+		}
+	case "stop":
+		session, err = recorder.Stop(input.ID)
+		if errors.Is(err, db.ErrSessionNotFound) {
+			http.Error(writer, err.Error(), http.StatusNotFound)
+			return
+		}
+	default:
+		http.Error(writer, "Поле 'action' должно быть \"start\" или \"stop\"", http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(writer).Encode(session); err != nil {
+		log.Printf("Ошибка сериализации ответа в Sessions: %v", err)
+	}
+}
+
+// GetSessionProcessHistory возвращает временной ряд процесса {pid} в сессии {id}.
+// Необязательные параметры from и to ограничивают интервал.
+func GetSessionProcessHistory(sqlDB *sql.DB) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodGet {
+			http.Error(writer, "Метод не разрешён. Используйте GET", http.StatusMethodNotAllowed)
+			return
+		}
+
+		sessionID, err := strconv.ParseInt(request.PathValue("id"), 10, 64)
+		if err != nil || sessionID <= 0 {
+			http.Error(writer, "Некорректный id сессии", http.StatusBadRequest)
+			return
+		}
+		pid, err := strconv.ParseInt(request.PathValue("pid"), 10, 32)
+		if err != nil || pid <= 0 {
+			http.Error(writer, "Некорректный PID. PID должен быть положительным числом", http.StatusBadRequest)
+			return
+		}
+
+		query := request.URL.Query()
+		from, err := parseTimeParam(query, "from")
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		to, err := parseTimeParam(query, "to")
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if _, err := db.GetSession(sqlDB, sessionID); err != nil {
+			if errors.Is(err, db.ErrSessionNotFound) {
+				http.Error(writer, err.Error(), http.StatusNotFound)
+				return
+			}
+			log.Printf("Ошибка получения сессии %d: %v", sessionID, err)
+			http.Error(writer, "Ошибка получения сессии", http.StatusInternalServerError)
+			return
+		}
+
+		samples, err := db.QuerySessionSamples(sqlDB, sessionID, int32(pid), from, to)
+		if err != nil {
+			log.Printf("Ошибка получения истории процесса %d в сессии %d: %v", pid, sessionID, err)
+			http.Error(writer, "Ошибка получения истории процесса", http.StatusInternalServerError)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(writer).Encode(models.SessionProcessHistory{
+			SessionID: sessionID,
+			PID:       int32(pid),
+			Samples:   samples,
+		}); err != nil {
+			log.Printf("Ошибка сериализации ответа в GetSessionProcessHistory: %v", err)
+		}
+	}
+}
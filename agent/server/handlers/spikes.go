@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/RZhurakovskiy/agent/server/db"
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/RZhurakovskiy/agent/server/services"
+)
+
+// GetSpikes возвращает записанные всплески с фильтрами pid, reason, from, to и limit.
+func GetSpikes(sqlDB *sql.DB) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodGet {
+			http.Error(writer, "Метод не разрешён. Используйте GET", http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := request.URL.Query()
+
+		pid, err := parseIntParam(query, "pid", 0)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		limit, err := parseIntParam(query, "limit", 500)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		from, err := parseTimeParam(query, "from")
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		to, err := parseTimeParam(query, "to")
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		spikes, err := db.QuerySpikes(sqlDB, db.SpikeFilter{
+			PID:    int32(pid),
+			Reason: query.Get("reason"),
+			From:   from,
+			To:     to,
+			Limit:  limit,
+		})
+		if err != nil {
+			log.Printf("Ошибка получения всплесков: %v", err)
+			http.Error(writer, "Ошибка получения всплесков", http.StatusInternalServerError)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(writer).Encode(spikes); err != nil {
+			log.Printf("Ошибка сериализации ответа в GetSpikes: %v", err)
+		}
+	}
+}
+
+// SpikeConfig возвращает (GET) или меняет (POST) пороги детектора всплесков.
+func SpikeConfig(recorder *services.SpikeRecorder) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		switch request.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			var input models.SpikeConfig
+			if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+				log.Printf("Ошибка декодирования JSON в SpikeConfig: %v", err)
+				http.Error(writer, "Некорректный JSON. Ожидается: {\"cpuThreshold\": <число>, \"rssThresholdMB\": <число>, \"minDurationSec\": <число>}", http.StatusBadRequest)
+				return
+			}
+			if err := recorder.SetConfig(input); err != nil {
+				http.Error(writer, err.Error(), http.StatusBadRequest)
+				return
+			}
+			log.Printf("Пороги всплесков изменены через API: CPU > %.1f%%, RSS > %d МБ", input.CPUThreshold, input.RSSThresholdMB)
+		default:
+			http.Error(writer, "Метод не разрешён. Используйте GET или POST", http.StatusMethodNotAllowed)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(writer).Encode(recorder.Config()); err != nil {
+			log.Printf("Ошибка сериализации ответа в SpikeConfig: %v", err)
+		}
+	}
+}
@@ -3,35 +3,64 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/RZhurakovskiy/agent/server/models"
 	"github.com/RZhurakovskiy/agent/server/services"
 )
 
-func StartProcess(writer http.ResponseWriter, request *http.Request) {
-	if request.Method != http.MethodPost {
-		http.Error(writer, "Только POST разрешён", http.StatusMethodNotAllowed)
-		return
-	}
+// StartProcess запускает разрешённую команду и записывает попытку в журнал аудита.
+func StartProcess(audit *services.AuditLog) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodPost {
+			http.Error(writer, "Только POST разрешён", http.StatusMethodNotAllowed)
+			return
+		}
 
-	var req models.StartProcessRequest
-	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
-		http.Error(writer, "Неверный JSON", http.StatusBadRequest)
-		return
-	}
+		entry := models.AuditEntry{
+			Action:     models.AuditActionStart,
+			ClientAddr: clientAddr(request),
+		}
 
-	result, err := services.StartProcess(req.Command, req.Args, req.Cwd)
-	if err != nil {
-		http.Error(writer, err.Error(), http.StatusBadRequest)
-		return
-	}
+		body, err := readAuditedBody(request)
+		entry.Params = string(body)
+		if err != nil {
+			entry.Outcome, entry.Error = models.AuditOutcomeRejected, err.Error()
+			audit.Record(entry)
+			http.Error(writer, "Не удалось прочитать запрос", http.StatusBadRequest)
+			return
+		}
+
+		var req models.StartProcessRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			entry.Outcome, entry.Error = models.AuditOutcomeRejected, err.Error()
+			audit.Record(entry)
+			http.Error(writer, "Неверный JSON", http.StatusBadRequest)
+			return
+		}
 
-	writer.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(writer).Encode(models.StartProcessResponse{
-		PID:     result.PID,
-		Command: req.Command,
-		Args:    req.Args,
-		Cwd:     req.Cwd,
-		Msg:     result.Msg,
-	})
+		entry.TargetName = req.Command
+		entry.TargetCmdline = strings.TrimSpace(req.Command + " " + req.Args)
+
+		result, err := services.StartProcess(req.Command, req.Args, req.Cwd)
+		if err != nil {
+			entry.Outcome, entry.Error = models.AuditOutcomeFailure, err.Error()
+			audit.Record(entry)
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		entry.PID = result.PID
+		entry.Outcome = models.AuditOutcomeSuccess
+		audit.Record(entry)
+
+		writer.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(writer).Encode(models.StartProcessResponse{
+			PID:     result.PID,
+			Command: req.Command,
+			Args:    req.Args,
+			Cwd:     req.Cwd,
+			Msg:     result.Msg,
+		})
+	}
 }
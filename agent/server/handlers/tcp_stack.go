@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/getmetrics"
+	"github.com/RZhurakovskiy/agent/server/ws"
+)
+
+// tcpStackCollector снимает счётчики TCP-стека по запросу, когда мониторинг выключен.
+// Скорости в этом случае считаются с предыдущего запроса.
+var tcpStackCollector = getmetrics.NewTCPStackCollector()
+
+// GetTCPStack возвращает скорости счётчиков TCP и UDP ядра: повторные отправки, сбросы,
+// переполнения очереди accept, SYN cookies и ошибки буферов UDP.
+// При включённом мониторинге отдаётся снимок из кэша, иначе данные снимаются на месте.
+func GetTCPStack(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		http.Error(writer, "Метод не разрешён. Используйте GET", http.StatusMethodNotAllowed)
+		return
+	}
+
+	stats, ok := ws.TCPStackSnapshot()
+	if !ok {
+		var err error
+		stats, err = tcpStackCollector.Collect()
+		if errors.Is(err, getmetrics.ErrTCPStackUnavailable) {
+			http.Error(writer, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		if err != nil {
+			log.Printf("Ошибка получения счётчиков TCP-стека: %v", err)
+			http.Error(writer, "Ошибка получения счётчиков TCP-стека", http.StatusInternalServerError)
+			return
+		}
+		stats.Timestamp = time.Now().Format("2006-01-02 15:04:05")
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(writer).Encode(stats); err != nil {
+		log.Printf("Ошибка сериализации ответа в GetTCPStack: %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package models
+
+// Действия, которые попадают в журнал аудита.
+const (
+	AuditActionKill  = "kill"  // Завершение процесса
+	AuditActionStart = "start" // Запуск процесса
+
+	AuditActionIntegrityRuleAdd    = "integrity-rule-add"    // Добавление правила в список разрешённых или запрещённых
+	AuditActionIntegrityRuleDelete = "integrity-rule-delete" // Удаление правила из списка
+)
+
+// Результаты действий в журнале аудита.
+const (
+	AuditOutcomeSuccess  = "success"  // Действие выполнено
+	AuditOutcomeFailure  = "failure"  // Действие не удалось выполнить
+	AuditOutcomeRejected = "rejected" // Запрос отклонён до выполнения (некорректные параметры)
+)
+
+/*
+AuditEntry представляет запись журнала аудита операций, меняющих состояние хоста или списки проверки целостности.
+- Используется в HTTP-эндпоинте /api/audit.
+- Params содержит тело исходного запроса.
+*/
+type AuditEntry struct {
+	ID            int64  `json:"id"`
+	Timestamp     string `json:"timestamp"`
+	Action        string `json:"action"`
+	PID           int32  `json:"pid"`
+	TargetName    string `json:"targetName"`
+	TargetCmdline string `json:"targetCmdline"`
+	Params        string `json:"params"`
+	ClientAddr    string `json:"clientAddr"`
+	Outcome       string `json:"outcome"`
+	Error         string `json:"error,omitempty"`
+}
+
+/*
+AuditPage представляет страницу журнала аудита.
+- Используется в HTTP-эндпоинте /api/audit.
+- Total - число записей, подходящих под фильтр, без учёта limit и offset.
+*/
+type AuditPage struct {
+	Total   int64        `json:"total"`
+	Limit   int          `json:"limit"`
+	Offset  int          `json:"offset"`
+	Entries []AuditEntry `json:"entries"`
+}
@@ -0,0 +1,22 @@
+package models
+
+/*
+CgroupStats представляет сводку по одной cgroup, в которой есть процессы.
+- Используется в HTTP-эндпоинте /api/cgroups.
+- CPU и память берутся из файлов cgroup v2 (cpu.stat, memory.current, memory.max), Source = "cgroup".
+- Если файлы недоступны (cgroup v1, не Linux), Source = "processes", а CPU и память - суммы по процессам группы.
+- CPUPercent - загрузка с предыдущего замера, где 100% = одно ядро; MemoryMax = 0 - без ограничения.
+*/
+type CgroupStats struct {
+	Path           string  `json:"path"`
+	ContainerID    string  `json:"containerId,omitempty"`
+	SystemdUnit    string  `json:"systemdUnit,omitempty"`
+	Processes      int     `json:"processes"`
+	PIDs           []int32 `json:"pids"`
+	CPUPercent     float64 `json:"cpuPercent"`
+	CPUHostPercent float64 `json:"cpuHostPercent"`
+	MemoryCurrent  uint64  `json:"memoryCurrent"`
+	MemoryMax      uint64  `json:"memoryMax"`
+	ProcessesRSS   uint64  `json:"processesRss"`
+	Source         string  `json:"source"`
+}
@@ -0,0 +1,55 @@
+package models
+
+/*
+Connection представляет TCP- или UDP-сокет процесса, включая переходные состояния TCP (CLOSE_WAIT, TIME_WAIT и т.д.).
+- Используется в HTTP-эндпоинте /api/connections и в WebSocket-эндпоинте /ws/connections.
+- Protocol - tcp, tcp6, udp или udp6; Status - состояние TCP (ESTABLISHED, CLOSE_WAIT, ...) или NONE для UDP.
+- PID = 0, если владелец сокета неизвестен: сокет в TIME_WAIT уже не принадлежит процессу, или нет прав на /proc/<pid>/fd.
+- У сокета без удалённой стороны (LISTEN, несвязанный UDP) RemoteAddr и RemoteIP пустые, RemotePort = 0.
+*/
+type Connection struct {
+	Protocol   string `json:"protocol"`
+	Family     string `json:"family"`
+	PID        int32  `json:"pid"`
+	Process    string `json:"process"`
+	Status     string `json:"status"`
+	LocalAddr  string `json:"localAddr"`
+	LocalIP    string `json:"localIp"`
+	LocalPort  uint32 `json:"localPort"`
+	RemoteAddr string `json:"remoteAddr"`
+	RemoteIP   string `json:"remoteIp"`
+	RemotePort uint32 `json:"remotePort"`
+}
+
+/*
+ConnectionHostCount - число соединений с одним удалённым адресом и их разбивка по состояниям.
+*/
+type ConnectionHostCount struct {
+	RemoteIP string         `json:"remoteIp"`
+	Count    int            `json:"count"`
+	States   map[string]int `json:"states"`
+}
+
+/*
+ConnectionProcessCount - число соединений процесса и их разбивка по состояниям.
+*/
+type ConnectionProcessCount struct {
+	PID     int32          `json:"pid"`
+	Process string         `json:"process"`
+	Count   int            `json:"count"`
+	States  map[string]int `json:"states"`
+}
+
+/*
+ConnectionsSummary представляет сводку по соединениям.
+- Используется в HTTP-эндпоинте /api/connections/summary и в WebSocket-эндпоинте /ws/connections.
+- ByRemoteHost и ByProcess упорядочены по убыванию числа соединений и ограничены параметром limit.
+- Сокеты без удалённой стороны (LISTEN, несвязанный UDP) не попадают в ByRemoteHost.
+*/
+type ConnectionsSummary struct {
+	Total        int                      `json:"total"`
+	ByState      map[string]int           `json:"byState"`
+	ByRemoteHost []ConnectionHostCount    `json:"byRemoteHost"`
+	ByProcess    []ConnectionProcessCount `json:"byProcess"`
+	Timestamp    string                   `json:"timestamp"`
+}
@@ -0,0 +1,28 @@
+package models
+
+/*
+CPUBreakdown представляет распределение времени CPU хоста по категориям за интервал, в процентах.
+- Используется в WebSocket-эндпоинте /ws/cpu.
+- Сумма всех полей равна 100. Высокие IOWait и Steal отличают ожидание диска и гипервизора от реальной загрузки.
+*/
+type CPUBreakdown struct {
+	User    float64 `json:"user"`
+	Nice    float64 `json:"nice"`
+	System  float64 `json:"system"`
+	Idle    float64 `json:"idle"`
+	IOWait  float64 `json:"iowait"`
+	IRQ     float64 `json:"irq"`
+	SoftIRQ float64 `json:"softirq"`
+	Steal   float64 `json:"steal"`
+}
+
+/*
+CPUStats представляет загрузку CPU хоста за интервал между замерами.
+- Total - общая загрузка (0-100), без учёта idle и iowait.
+- Cores - загрузка каждого логического ядра (0-100) в порядке нумерации ядер.
+*/
+type CPUStats struct {
+	Total     float64
+	Cores     []float64
+	Breakdown CPUBreakdown
+}
@@ -0,0 +1,42 @@
+package models
+
+/*
+DiskUsage представляет заполненность одной точки монтирования.
+- Используется в HTTP-эндпоинте /api/disk и WebSocket-эндпоинте /ws/disk.
+- Объёмы указаны в байтах.
+*/
+type DiskUsage struct {
+	Mountpoint        string  `json:"mountpoint"`
+	Device            string  `json:"device"`
+	Fstype            string  `json:"fstype"`
+	Total             uint64  `json:"total"`
+	Used              uint64  `json:"used"`
+	Free              uint64  `json:"free"`
+	UsedPercent       float64 `json:"usedPercent"`
+	InodesTotal       uint64  `json:"inodesTotal"`
+	InodesUsed        uint64  `json:"inodesUsed"`
+	InodesUsedPercent float64 `json:"inodesUsedPercent"`
+}
+
+/*
+DiskIO представляет ввод-вывод блочного устройства за интервал между замерами.
+- UtilPercent - доля времени, когда на устройстве были запросы в обработке (как %util в iostat).
+*/
+type DiskIO struct {
+	Device           string  `json:"device"`
+	ReadBytesPerSec  float64 `json:"readBytesPerSec"`
+	WriteBytesPerSec float64 `json:"writeBytesPerSec"`
+	ReadIOPS         float64 `json:"readIops"`
+	WriteIOPS        float64 `json:"writeIops"`
+	UtilPercent      float64 `json:"utilPercent"`
+}
+
+/*
+DiskStats представляет снимок состояния дисков: заполненность точек монтирования и ввод-вывод устройств.
+- Используется в HTTP-эндпоинте /api/disk и WebSocket-эндпоинте /ws/disk.
+*/
+type DiskStats struct {
+	Mounts    []DiskUsage `json:"mounts"`
+	Devices   []DiskIO    `json:"devices"`
+	Timestamp string      `json:"timestamp"`
+}
@@ -0,0 +1,56 @@
+package models
+
+// Имена метрик, которые сохраняются в историю.
+const (
+	MetricCPU           = "cpu"             // Загрузка CPU хоста, %
+	MetricMemory        = "memory"          // Использование памяти хоста, %
+	MetricLoad1         = "load1"           // Средняя загрузка за 1 минуту
+	MetricPSICPUSome    = "psi.cpu.some"    // PSI CPU some avg10, %
+	MetricPSIMemorySome = "psi.memory.some" // PSI memory some avg10, %
+	MetricPSIMemoryFull = "psi.memory.full" // PSI memory full avg10, %
+	MetricPSIIOSome     = "psi.io.some"     // PSI io some avg10, %
+	MetricPSIIOFull     = "psi.io.full"     // PSI io full avg10, %
+
+	MetricTCPRetrans         = "tcp.retrans"          // Повторно отправленные TCP-сегменты в секунду
+	MetricTCPOutRsts         = "tcp.out_rsts"         // Отправленные RST в секунду
+	MetricTCPEstabResets     = "tcp.estab_resets"     // Сброшенные TCP-соединения в секунду
+	MetricTCPListenOverflows = "tcp.listen_overflows" // Переполнения очереди accept в секунду
+	MetricTCPListenDrops     = "tcp.listen_drops"     // Отброшенные входящие соединения в секунду
+	MetricUDPRcvbufErrors    = "udp.rcvbuf_errors"    // UDP-датаграммы, отброшенные из-за полного буфера, в секунду
+)
+
+/*
+HistoryPoint представляет одну точку истории метрики.
+- Для сырых замеров Min, Avg и Max совпадают.
+*/
+type HistoryPoint struct {
+	TS        int64   `json:"ts"`
+	Timestamp string  `json:"timestamp"`
+	Min       float64 `json:"min"`
+	Avg       float64 `json:"avg"`
+	Max       float64 `json:"max"`
+}
+
+/*
+HistoryResponse представляет ответ API с историей метрики.
+- Используется в HTTP-эндпоинте /api/history/{metric}.
+- Resolution - выбранный источник данных: "raw", "1m" или "1h"; Step - шаг точек в секундах.
+*/
+type HistoryResponse struct {
+	Metric     string         `json:"metric"`
+	Resolution string         `json:"resolution"`
+	Step       int64          `json:"step"`
+	From       string         `json:"from"`
+	To         string         `json:"to"`
+	Points     []HistoryPoint `json:"points"`
+}
+
+/*
+HistoryConfig представляет сроки хранения истории метрик.
+- Используется в HTTP-эндпоинте /api/history/config.
+*/
+type HistoryConfig struct {
+	RawRetentionHours   int `json:"rawRetentionHours"`
+	MinuteRetentionDays int `json:"minuteRetentionDays"`
+	HourRetentionDays   int `json:"hourRetentionDays"`
+}
@@ -0,0 +1,46 @@
+package models
+
+// Вердикты проверки целостности исполняемого файла процесса.
+const (
+	IntegrityKnown   = "known"   // Хэш или путь в списке разрешённых
+	IntegrityUnknown = "unknown" // Хэша и пути нет ни в одном списке
+	IntegrityDenied  = "denied"  // Хэш или путь в списке запрещённых
+)
+
+// Списки и виды правил целостности.
+const (
+	IntegrityListAllow = "allow" // Список разрешённых
+	IntegrityListDeny  = "deny"  // Список запрещённых
+	IntegrityKindHash  = "hash"  // Значение - SHA-256 исполняемого файла в hex
+	IntegrityKindPath  = "path"  // Значение - путь к файлу или каталог с "/" на конце
+)
+
+/*
+IntegrityRule представляет правило списка разрешённых или запрещённых исполняемых файлов.
+- Используется в HTTP-эндпоинте /api/integrity/rules.
+- Правило запрета сильнее правила разрешения: процесс, подходящий под оба, получает вердикт denied.
+- Правило по пути с "/" на конце действует на все файлы каталога и его подкаталогов.
+*/
+type IntegrityRule struct {
+	ID        int64  `json:"id"`
+	List      string `json:"list"`
+	Kind      string `json:"kind"`
+	Value     string `json:"value"`
+	Comment   string `json:"comment"`
+	Timestamp string `json:"timestamp"`
+}
+
+/*
+IntegrityEvent представляет первое появление исполняемого файла с неизвестным хэшем.
+- Используется в HTTP-эндпоинте /api/integrity/events.
+- Событие по каждому хэшу создаётся один раз; PID, имя и пользователь - процесса, в котором хэш замечен впервые.
+*/
+type IntegrityEvent struct {
+	ID        int64  `json:"id"`
+	Timestamp string `json:"timestamp"`
+	SHA256    string `json:"sha256"`
+	Exe       string `json:"exe"`
+	PID       int32  `json:"pid"`
+	Name      string `json:"name"`
+	Username  string `json:"username"`
+}
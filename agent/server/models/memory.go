@@ -0,0 +1,26 @@
+package models
+
+/*
+MemoryStats представляет расширенное состояние памяти хоста. Объёмы указаны в байтах.
+- Available - память, доступная новым процессам без ухода в swap (с учётом освобождаемого кэша).
+- SwapInRate и SwapOutRate - скорость подкачки в байтах в секунду с предыдущего замера.
+- OOMKills - число процессов, завершённых OOM killer с загрузки системы; OOMKillsDelta - с предыдущего замера.
+*/
+type MemoryStats struct {
+	UsedPercent     float64
+	Total           uint64
+	Used            uint64
+	Available       uint64
+	Cached          uint64
+	Buffers         uint64
+	Shared          uint64
+	Slab            uint64
+	Dirty           uint64
+	SwapTotal       uint64
+	SwapUsed        uint64
+	SwapUsedPercent float64
+	SwapInRate      float64
+	SwapOutRate     float64
+	OOMKills        uint64
+	OOMKillsDelta   uint64
+}
@@ -0,0 +1,27 @@
+package models
+
+/*
+NetworkInterface представляет трафик сетевого интерфейса за интервал между замерами.
+- Используется в HTTP-эндпоинте /api/network и WebSocket-эндпоинте /ws/network.
+- Все значения - скорости в единицу времени (байты, пакеты, ошибки и отброшенные пакеты в секунду).
+*/
+type NetworkInterface struct {
+	Name            string  `json:"name"`
+	RxBytesPerSec   float64 `json:"rxBytesPerSec"`
+	TxBytesPerSec   float64 `json:"txBytesPerSec"`
+	RxPacketsPerSec float64 `json:"rxPacketsPerSec"`
+	TxPacketsPerSec float64 `json:"txPacketsPerSec"`
+	RxErrorsPerSec  float64 `json:"rxErrorsPerSec"`
+	TxErrorsPerSec  float64 `json:"txErrorsPerSec"`
+	RxDroppedPerSec float64 `json:"rxDroppedPerSec"`
+	TxDroppedPerSec float64 `json:"txDroppedPerSec"`
+}
+
+/*
+NetworkStats представляет снимок трафика всех сетевых интерфейсов хоста.
+- Используется в HTTP-эндпоинте /api/network и WebSocket-эндпоинте /ws/network.
+*/
+type NetworkStats struct {
+	Interfaces []NetworkInterface `json:"interfaces"`
+	Timestamp  string             `json:"timestamp"`
+}
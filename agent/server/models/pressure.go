@@ -0,0 +1,48 @@
+package models
+
+/*
+PSIValues представляет долю времени (%), в течение которой задачи простаивали из-за нехватки ресурса,
+усреднённую за 10, 60 и 300 секунд.
+*/
+type PSIValues struct {
+	Avg10  float64 `json:"avg10"`
+	Avg60  float64 `json:"avg60"`
+	Avg300 float64 `json:"avg300"`
+}
+
+/*
+PSIResource представляет Pressure Stall Information по одному ресурсу.
+- Some - хотя бы одна задача ждала ресурс; Full - ждали все активные задачи одновременно.
+- Full отсутствует для CPU на ядрах до 5.13.
+*/
+type PSIResource struct {
+	Some PSIValues  `json:"some"`
+	Full *PSIValues `json:"full,omitempty"`
+}
+
+/*
+PSIStats представляет Pressure Stall Information из /proc/pressure.
+- Ресурс отсутствует, если ядро не поддерживает PSI или оно выключено.
+*/
+type PSIStats struct {
+	CPU    *PSIResource `json:"cpu,omitempty"`
+	Memory *PSIResource `json:"memory,omitempty"`
+	IO     *PSIResource `json:"io,omitempty"`
+}
+
+/*
+SystemPressure представляет общую нагрузку на систему.
+- Передаётся в WebSocket-эндпоинте /ws/cpu в поле pressure.
+- ProcsRunning и ProcsBlocked - задачи в очереди на CPU и в ожидании ввода-вывода.
+- PSI равен nil, если /proc/pressure недоступен (не Linux или ядро без PSI).
+*/
+type SystemPressure struct {
+	Load1        float64   `json:"load1"`
+	Load5        float64   `json:"load5"`
+	Load15       float64   `json:"load15"`
+	UptimeSec    uint64    `json:"uptimeSec"`
+	BootTime     string    `json:"bootTime"`
+	ProcsRunning int       `json:"procsRunning"`
+	ProcsBlocked int       `json:"procsBlocked"`
+	PSI          *PSIStats `json:"psi,omitempty"`
+}
@@ -7,20 +7,31 @@ package models
 /*
 ProcessInfo представляет информацию о системном процессе.
 - Используется для передачи данных о процессах через API и WebSocket.
+- CPUPercent - загрузка за последний интервал сбора, где 100% = одно ядро (как в top).
+- CPUHostPercent - та же загрузка в долях всех ядер хоста; сумма по процессам сопоставима с /ws/cpu.
+- Cgroup - путь cgroup процесса; ContainerID и SystemdUnit извлекаются из него, если это возможно.
+- SHA256 и Verdict (known, unknown, denied) заполняет проверка целостности; пусто, если исполняемый файл недоступен.
 */
 type ProcessInfo struct {
-	PID           int32    `json:"pid"`
-	Name          string   `json:"name"`
-	Exe           string   `json:"exe"`
-	Cmdline       string   `json:"cmdline"`
-	Username      string   `json:"username"`
-	Status        string   `json:"status"`
-	CreateTime    int64    `json:"createTime"`
-	ParentPID     int32    `json:"parentPid"`
-	CPUPercent    float64  `json:"cpuPercent"`
-	MemoryPercent float64  `json:"memoryPercent"`
-	MemoryRSS     uint64   `json:"memoryRss"`
-	Ports         []uint32 `json:"ports"`
+	PID            int32    `json:"pid"`
+	Name           string   `json:"name"`
+	Exe            string   `json:"exe"`
+	Cmdline        string   `json:"cmdline"`
+	Username       string   `json:"username"`
+	Status         string   `json:"status"`
+	CreateTime     int64    `json:"createTime"`
+	ParentPID      int32    `json:"parentPid"`
+	CPUPercent     float64  `json:"cpuPercent"`
+	CPUHostPercent float64  `json:"cpuHostPercent"`
+	MemoryPercent  float64  `json:"memoryPercent"`
+	MemoryRSS      uint64   `json:"memoryRss"`
+	MemoryVMS      uint64   `json:"memoryVms"`
+	Ports          []uint32 `json:"ports"`
+	Cgroup         string   `json:"cgroup,omitempty"`
+	ContainerID    string   `json:"containerId,omitempty"`
+	SystemdUnit    string   `json:"systemdUnit,omitempty"`
+	SHA256         string   `json:"sha256,omitempty"`
+	Verdict        string   `json:"verdict,omitempty"`
 }
 
 /*
@@ -72,17 +83,24 @@ type MonitoringStatusResponse struct {
 }
 
 /*
-ListeningPort представляет ответ API о состоянии наличии LISTEN tcp портов.
-- Используется в HTTP-эндпоинте /api/istening-ports.
+ListeningPort представляет сокет в ответе API о занятых портах: TCP, UDP (IPv4 и IPv6) и именованные UNIX-сокеты.
+- Используется в HTTP-эндпоинте /api/listening-ports.
+- Protocol - tcp, tcp6, udp, udp6 или unix; Family - ipv4, ipv6 или unix.
+- Status - состояние в терминах ОС (LISTEN, ESTABLISHED, NONE для UDP; LISTEN, CONNECTED, UNCONNECTED для UNIX), State - нормализованное: listen, established или other.
+- У UNIX-сокетов Port равен 0, Path - путь сокета (абстрактные имена начинаются с @), SocketType - stream, dgram или seqpacket.
 */
 type ListeningPort struct {
 	Port       uint32 `json:"port"`
 	Protocol   string `json:"protocol"`
+	Family     string `json:"family"`
 	PID        int32  `json:"pid"`
 	Process    string `json:"process"`
 	Status     string `json:"status"`
+	State      string `json:"state"`
 	LocalAddr  string `json:"localAddr"`
 	RemoteAddr string `json:"remoteAddr"`
+	Path       string `json:"path,omitempty"`
+	SocketType string `json:"socketType,omitempty"`
 }
 
 type StartProcessRequest struct {
@@ -98,3 +116,16 @@ type StartProcessResponse struct {
 	Cwd     string `json:"cwd"`
 	Msg     string `json:"msg"`
 }
+
+/*
+ProcessPage представляет окно отфильтрованного и отсортированного списка процессов.
+- Используется в HTTP-эндпоинте /api/processes и в WebSocket /ws/processes с параметрами выборки.
+- Total - число процессов, подходящих под фильтры, до применения offset и limit; Limit = 0 - без ограничения.
+*/
+type ProcessPage struct {
+	Total     int           `json:"total"`
+	Offset    int           `json:"offset"`
+	Limit     int           `json:"limit"`
+	Processes []ProcessInfo `json:"processes"`
+	Timestamp string        `json:"timestamp"`
+}
@@ -0,0 +1,42 @@
+package models
+
+// Типы сообщений /ws/processes?encoding=delta.
+const (
+	ProcessStreamSnapshot = "snapshot" // Полный список процессов
+	ProcessStreamDelta    = "delta"    // Изменения относительно сообщения с номером Seq-1
+)
+
+/*
+ProcessSnapshot представляет полный список процессов в потоке /ws/processes?encoding=delta.
+- Отправляется при подключении, по запросу resync и если клиент отстал больше чем на одно обновление.
+- Seq - номер обновления кэша процессов; следующая разница придёт с номером Seq+1.
+*/
+type ProcessSnapshot struct {
+	Type      string        `json:"type"`
+	Seq       uint64        `json:"seq"`
+	Processes []ProcessInfo `json:"processes"`
+	Timestamp string        `json:"timestamp"`
+}
+
+/*
+ProcessDelta представляет изменения списка процессов между обновлениями Seq-1 и Seq.
+- Используется в WebSocket /ws/processes?encoding=delta.
+- Применяется в порядке removed, added, changed: процесс с переиспользованным PID приходит в removed и added.
+*/
+type ProcessDelta struct {
+	Type      string          `json:"type"`
+	Seq       uint64          `json:"seq"`
+	Added     []ProcessInfo   `json:"added"`
+	Removed   []int32         `json:"removed"`
+	Changed   []ProcessChange `json:"changed"`
+	Timestamp string          `json:"timestamp"`
+}
+
+/*
+ProcessChange представляет изменённые поля одного процесса.
+- Fields содержит только изменившиеся поля под теми же именами, что в ProcessInfo; пустая строка или null - поле очищено.
+*/
+type ProcessChange struct {
+	PID    int32          `json:"pid"`
+	Fields map[string]any `json:"fields"`
+}
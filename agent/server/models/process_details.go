@@ -0,0 +1,115 @@
+package models
+
+/*
+ProcessOpenFile представляет открытый процессом файл.
+*/
+type ProcessOpenFile struct {
+	FD   uint64 `json:"fd"`
+	Path string `json:"path"`
+}
+
+/*
+ProcessConnection представляет сетевое соединение процесса.
+- Status - состояние TCP ("LISTEN", "ESTABLISHED", ...) или "NONE" для UDP.
+*/
+type ProcessConnection struct {
+	FD         uint32 `json:"fd"`
+	Family     string `json:"family"`
+	Type       string `json:"type"`
+	LocalAddr  string `json:"localAddr"`
+	RemoteAddr string `json:"remoteAddr"`
+	Status     string `json:"status"`
+}
+
+/*
+ProcessIOCounters представляет счётчики ввода-вывода процесса с момента запуска.
+- ReadBytes/WriteBytes учитывают весь ввод-вывод, DiskReadBytes/DiskWriteBytes - только обращения к диску (Linux).
+*/
+type ProcessIOCounters struct {
+	ReadCount      uint64 `json:"readCount"`
+	WriteCount     uint64 `json:"writeCount"`
+	ReadBytes      uint64 `json:"readBytes"`
+	WriteBytes     uint64 `json:"writeBytes"`
+	DiskReadBytes  uint64 `json:"diskReadBytes"`
+	DiskWriteBytes uint64 `json:"diskWriteBytes"`
+}
+
+/*
+ProcessRlimit представляет ограничение ресурса процесса.
+- SoftUnlimited и HardUnlimited отмечают значение "unlimited"; в этом случае Soft или Hard равны 0.
+*/
+type ProcessRlimit struct {
+	Resource      string `json:"resource"`
+	Soft          uint64 `json:"soft"`
+	Hard          uint64 `json:"hard"`
+	Used          uint64 `json:"used"`
+	SoftUnlimited bool   `json:"softUnlimited"`
+	HardUnlimited bool   `json:"hardUnlimited"`
+}
+
+/*
+ProcessMemoryMaps представляет сводку по отображениям памяти процесса (/proc/<pid>/smaps), в байтах.
+*/
+type ProcessMemoryMaps struct {
+	Mappings     int    `json:"mappings"`
+	Size         uint64 `json:"size"`
+	RSS          uint64 `json:"rss"`
+	PSS          uint64 `json:"pss"`
+	SharedClean  uint64 `json:"sharedClean"`
+	SharedDirty  uint64 `json:"sharedDirty"`
+	PrivateClean uint64 `json:"privateClean"`
+	PrivateDirty uint64 `json:"privateDirty"`
+	Anonymous    uint64 `json:"anonymous"`
+	Swap         uint64 `json:"swap"`
+}
+
+/*
+ProcessChild представляет прямого потомка процесса.
+*/
+type ProcessChild struct {
+	PID  int32  `json:"pid"`
+	Name string `json:"name"`
+}
+
+/*
+ProcessDetails представляет подробную информацию о процессе для отладки.
+- Используется в HTTP-эндпоинте /api/processes/{pid}.
+- Значения переменных окружения, похожих на секреты, заменяются на "***".
+- OpenFiles ограничен первой тысячей записей; полное число открытых дескрипторов - в NumFDs.
+- Unavailable содержит причины, по которым часть полей не удалось прочитать (например, нет прав).
+*/
+type ProcessDetails struct {
+	PID            int32               `json:"pid"`
+	Name           string              `json:"name"`
+	Exe            string              `json:"exe"`
+	Cmdline        string              `json:"cmdline"`
+	Cwd            string              `json:"cwd"`
+	Username       string              `json:"username"`
+	Status         string              `json:"status"`
+	CreateTime     int64               `json:"createTime"`
+	ParentPID      int32               `json:"parentPid"`
+	Terminal       string              `json:"terminal"`
+	Nice           int32               `json:"nice"`
+	Priority       int32               `json:"priority"`
+	Uids           []uint32            `json:"uids"`
+	Gids           []uint32            `json:"gids"`
+	Groups         []uint32            `json:"groups"`
+	NumThreads     int32               `json:"numThreads"`
+	NumFDs         int32               `json:"numFds"`
+	CtxSwitchesVol int64               `json:"ctxSwitchesVoluntary"`
+	CtxSwitchesInv int64               `json:"ctxSwitchesInvoluntary"`
+	CPUUser        float64             `json:"cpuUserSec"`
+	CPUSystem      float64             `json:"cpuSystemSec"`
+	MemoryRSS      uint64              `json:"memoryRss"`
+	MemoryVMS      uint64              `json:"memoryVms"`
+	MemorySwap     uint64              `json:"memorySwap"`
+	IO             *ProcessIOCounters  `json:"io,omitempty"`
+	MemoryMaps     *ProcessMemoryMaps  `json:"memoryMaps,omitempty"`
+	Rlimits        []ProcessRlimit     `json:"rlimits"`
+	Cgroups        []string            `json:"cgroups"`
+	Env            map[string]string   `json:"env"`
+	OpenFiles      []ProcessOpenFile   `json:"openFiles"`
+	Connections    []ProcessConnection `json:"connections"`
+	Children       []ProcessChild      `json:"children"`
+	Unavailable    map[string]string   `json:"unavailable,omitempty"`
+}
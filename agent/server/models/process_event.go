@@ -0,0 +1,27 @@
+package models
+
+// Типы событий жизненного цикла процесса.
+const (
+	ProcessEventStarted = "started" // Процесс появился между двумя снимками
+	ProcessEventExited  = "exited"  // Процесс пропал между двумя снимками
+)
+
+/*
+ProcessEvent представляет событие жизненного цикла процесса, вычисленное по разнице снимков.
+- Используется в HTTP-эндпоинте /api/process-events и WebSocket /ws/process-events.
+- Timestamp - время снимка, в котором событие обнаружено; CreateTime - время запуска процесса (мс).
+- LifetimeSec для exited - сколько процесс прожил от запуска до последнего снимка, где он был виден.
+*/
+type ProcessEvent struct {
+	ID          int64  `json:"id"`
+	Type        string `json:"type"`
+	Timestamp   string `json:"timestamp"`
+	PID         int32  `json:"pid"`
+	CreateTime  int64  `json:"createTime"`
+	Name        string `json:"name"`
+	Exe         string `json:"exe"`
+	Cmdline     string `json:"cmdline"`
+	Username    string `json:"username"`
+	ParentPID   int32  `json:"parentPid"`
+	LifetimeSec int64  `json:"lifetimeSec"`
+}
@@ -0,0 +1,17 @@
+package models
+
+/*
+ProcessNode представляет процесс в дереве процессов вместе с его потомками.
+- Используется в HTTP-эндпоинте /api/process-tree и в режиме mode=tree WebSocket-эндпоинта /ws/processes.
+- Subtree* - суммы по процессу и всем его потомкам.
+- Orphaned отмечает процесс, родителя которого нет в снимке или чей PID родителя занят более новым процессом.
+*/
+type ProcessNode struct {
+	ProcessInfo
+	SubtreeCPUPercent     float64        `json:"subtreeCpuPercent"`
+	SubtreeCPUHostPercent float64        `json:"subtreeCpuHostPercent"`
+	SubtreeMemoryRSS      uint64         `json:"subtreeMemoryRss"`
+	SubtreeProcesses      int            `json:"subtreeProcesses"`
+	Orphaned              bool           `json:"orphaned"`
+	Children              []*ProcessNode `json:"children"`
+}
@@ -0,0 +1,43 @@
+package models
+
+// Уровни серьёзности находок проверки процессов в порядке возрастания.
+const (
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+/*
+SecurityFinding представляет срабатывание одной эвристики проверки подозрительных процессов.
+- Используется в HTTP-эндпоинте /api/security/findings и в CLI-меню проверки процессов.
+- Rule - идентификатор эвристики (deleted-exe, temp-exe, hidden-dir-exe, name-mismatch, unusual-listener, root-network, cpu-miner).
+- Explanation объясняет, что именно найдено и почему это подозрительно, с учётом безобидных причин.
+*/
+type SecurityFinding struct {
+	Rule        string  `json:"rule"`
+	Severity    string  `json:"severity"`
+	PID         int32   `json:"pid"`
+	Name        string  `json:"name"`
+	Exe         string  `json:"exe"`
+	Username    string  `json:"username"`
+	CPUPercent  float64 `json:"cpuPercent"`
+	Title       string  `json:"title"`
+	Explanation string  `json:"explanation"`
+}
+
+/*
+SecurityReport представляет результат проверки подозрительных процессов.
+- Используется в HTTP-эндпоинте /api/security/findings.
+- Findings упорядочены по убыванию серьёзности; BySeverity - число находок каждого уровня до фильтрации.
+- Integrity - число процессов по вердиктам проверки целостности (known, unknown, denied); нет, если проверка не подключена.
+- PendingHashes - число процессов, хэш исполняемого файла которых ещё считается в фоне (вердикт пока только по путям).
+*/
+type SecurityReport struct {
+	Scanned       int               `json:"scanned"`
+	BySeverity    map[string]int    `json:"bySeverity"`
+	Findings      []SecurityFinding `json:"findings"`
+	Integrity     map[string]int    `json:"integrity,omitempty"`
+	PendingHashes int               `json:"pendingHashes,omitempty"`
+	Timestamp     string            `json:"timestamp"`
+}
@@ -0,0 +1,50 @@
+package models
+
+/*
+Session представляет сессию записи метрик процессов.
+- Используется в HTTP-эндпоинте /api/sessions.
+- StoppedAt пуст, пока сессия активна.
+*/
+type Session struct {
+	ID           int64   `json:"id"`
+	Name         string  `json:"name"`
+	CPUThreshold float64 `json:"cpuThreshold"`
+	IntervalSec  int     `json:"intervalSec"`
+	StartedAt    string  `json:"startedAt"`
+	StoppedAt    string  `json:"stoppedAt,omitempty"`
+	Active       bool    `json:"active"`
+}
+
+/*
+SessionRequest представляет запрос на запуск или остановку сессии записи.
+- Используется в HTTP-эндпоинте POST /api/sessions.
+- Для action="start" используются Name, CPUThreshold и IntervalSec, для action="stop" - ID.
+*/
+type SessionRequest struct {
+	Action       string  `json:"action"`
+	ID           int64   `json:"id"`
+	Name         string  `json:"name"`
+	CPUThreshold float64 `json:"cpuThreshold"`
+	IntervalSec  int     `json:"intervalSec"`
+}
+
+/*
+SessionSample представляет одну точку временного ряда процесса внутри сессии.
+*/
+type SessionSample struct {
+	Timestamp     string  `json:"timestamp"`
+	Name          string  `json:"name"`
+	CPUPercent    float64 `json:"cpuPercent"`
+	MemoryPercent float64 `json:"memoryPercent"`
+	MemoryRSS     uint64  `json:"memoryRss"`
+}
+
+/*
+SessionProcessHistory представляет историю процесса в рамках сессии.
+- Используется в HTTP-эндпоинте /api/sessions/{id}/processes/{pid}.
+*/
+type SessionProcessHistory struct {
+	SessionID int64           `json:"sessionId"`
+	PID       int32           `json:"pid"`
+	Samples   []SessionSample `json:"samples"`
+}
@@ -0,0 +1,35 @@
+package models
+
+// Причины записи всплеска в таблицу spikes.
+const (
+	SpikeReasonCPU = "high_cpu" // Загрузка CPU процессом выше порога
+	SpikeReasonRSS = "high_rss" // Резидентная память процесса выше порога
+)
+
+/*
+Spike представляет один эпизод всплеска нагрузки процесса.
+- Используется в HTTP-эндпоинте /api/spikes.
+- CPUPercent, MemoryRSS и MemoryVMS содержат пиковые значения за эпизод.
+*/
+type Spike struct {
+	ID          int64   `json:"id"`
+	DetectedAt  string  `json:"detectedAt"`
+	PID         int32   `json:"pid"`
+	Name        string  `json:"name"`
+	CPUPercent  float64 `json:"cpuPercent"`
+	MemoryRSS   uint64  `json:"memoryRss"`
+	MemoryVMS   uint64  `json:"memoryVms"`
+	DurationSec int64   `json:"durationSec"`
+	Reason      string  `json:"reason"`
+}
+
+/*
+SpikeConfig представляет пороги детектора всплесков.
+- Используется в HTTP-эндпоинте /api/spikes/config.
+- Нулевой порог отключает соответствующую проверку.
+*/
+type SpikeConfig struct {
+	CPUThreshold   float64 `json:"cpuThreshold"`
+	RSSThresholdMB uint64  `json:"rssThresholdMB"`
+	MinDurationSec int64   `json:"minDurationSec"`
+}
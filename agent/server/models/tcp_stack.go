@@ -0,0 +1,51 @@
+package models
+
+/*
+TCPCounters представляет счётчики TCP-стека ядра за интервал между замерами.
+- Значения *PerSec - скорости в секунду по приросту счётчиков /proc/net/snmp (Tcp) и /proc/net/netstat (TcpExt).
+- RetransPercent - доля повторно отправленных сегментов среди всех отправленных, %; CurrEstab - текущее число соединений ESTABLISHED и CLOSE_WAIT.
+- EstabResets - соединения, сброшенные из ESTABLISHED или CLOSE_WAIT (в основном полученным RST); OutRsts - отправленные RST.
+- ListenOverflows и ListenDrops - соединения, отброшенные из-за переполнения очереди accept (частая причина «connection refused» под нагрузкой).
+*/
+type TCPCounters struct {
+	ActiveOpensPerSec      float64 `json:"activeOpensPerSec"`
+	PassiveOpensPerSec     float64 `json:"passiveOpensPerSec"`
+	AttemptFailsPerSec     float64 `json:"attemptFailsPerSec"`
+	EstabResetsPerSec      float64 `json:"estabResetsPerSec"`
+	OutRstsPerSec          float64 `json:"outRstsPerSec"`
+	InSegsPerSec           float64 `json:"inSegsPerSec"`
+	OutSegsPerSec          float64 `json:"outSegsPerSec"`
+	RetransSegsPerSec      float64 `json:"retransSegsPerSec"`
+	RetransPercent         float64 `json:"retransPercent"`
+	InErrsPerSec           float64 `json:"inErrsPerSec"`
+	ListenOverflowsPerSec  float64 `json:"listenOverflowsPerSec"`
+	ListenDropsPerSec      float64 `json:"listenDropsPerSec"`
+	SyncookiesSentPerSec   float64 `json:"syncookiesSentPerSec"`
+	SyncookiesRecvPerSec   float64 `json:"syncookiesRecvPerSec"`
+	SyncookiesFailedPerSec float64 `json:"syncookiesFailedPerSec"`
+	CurrEstab              uint64  `json:"currEstab"`
+}
+
+/*
+UDPCounters представляет счётчики UDP ядра (/proc/net/snmp, Udp) в секунду за интервал между замерами.
+- NoPorts - датаграммы на порт, который никто не слушает; RcvbufErrors - датаграммы, отброшенные из-за полного буфера приёма сокета.
+*/
+type UDPCounters struct {
+	InDatagramsPerSec  float64 `json:"inDatagramsPerSec"`
+	OutDatagramsPerSec float64 `json:"outDatagramsPerSec"`
+	NoPortsPerSec      float64 `json:"noPortsPerSec"`
+	InErrorsPerSec     float64 `json:"inErrorsPerSec"`
+	RcvbufErrorsPerSec float64 `json:"rcvbufErrorsPerSec"`
+	SndbufErrorsPerSec float64 `json:"sndbufErrorsPerSec"`
+}
+
+/*
+TCPStackStats представляет снимок счётчиков TCP/UDP-стека ядра Linux.
+- Используется в HTTP-эндпоинте /api/network/stack.
+- Счётчики относятся к сетевому пространству имён агента: внутри контейнера это сеть контейнера, а не хоста.
+*/
+type TCPStackStats struct {
+	TCP       TCPCounters `json:"tcp"`
+	UDP       UDPCounters `json:"udp"`
+	Timestamp string      `json:"timestamp"`
+}
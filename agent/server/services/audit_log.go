@@ -0,0 +1,32 @@
+package services
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/db"
+	"github.com/RZhurakovskiy/agent/server/models"
+)
+
+// AuditLog записывает в SQLite операции, меняющие состояние хоста (завершение и запуск процессов),
+// и изменения списков проверки целостности.
+type AuditLog struct {
+	sqlDB *sql.DB
+}
+
+// NewAuditLog создаёт журнал аудита.
+func NewAuditLog(sqlDB *sql.DB) *AuditLog {
+	return &AuditLog{sqlDB: sqlDB}
+}
+
+// Record сохраняет запись журнала. Ошибка записи не прерывает операцию, а только логируется.
+// У nil-журнала метод ничего не делает.
+func (a *AuditLog) Record(entry models.AuditEntry) {
+	if a == nil {
+		return
+	}
+	if err := db.InsertAuditEntry(a.sqlDB, entry, time.Now()); err != nil {
+		log.Printf("Ошибка записи журнала аудита: %v", err)
+	}
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/db"
+	"github.com/RZhurakovskiy/agent/server/models"
+)
+
+// DefaultHistoryConfig - сроки хранения истории метрик по умолчанию.
+var DefaultHistoryConfig = models.HistoryConfig{
+	RawRetentionHours:   24,
+	MinuteRetentionDays: 7,
+	HourRetentionDays:   365,
+}
+
+const (
+	// historyFlushInterval - как часто накопленные замеры пишутся в базу одной транзакцией.
+	historyFlushInterval = 10 * time.Second
+	// historyRollupInterval - как часто строятся агрегаты и удаляются устаревшие данные.
+	historyRollupInterval = time.Minute
+	// historyMaxPoints - сколько точек отдаётся, если клиент не указал шаг.
+	historyMaxPoints = 500
+)
+
+// ErrInvalidHistoryRange - интервал запроса истории пуст или перевёрнут.
+var ErrInvalidHistoryRange = errors.New("from должен быть раньше to")
+
+// historySource - источник данных для ответа: сырые замеры или корзины одного разрешения.
+type historySource struct {
+	name       string
+	resolution int64
+	retention  time.Duration
+}
+
+// HistoryRecorder сохраняет замеры метрик хоста в SQLite, строит из них минутные
+// и часовые агрегаты (min/avg/max) и удаляет данные старше сроков хранения.
+type HistoryRecorder struct {
+	sqlDB   *sql.DB
+	mu      sync.Mutex
+	config  models.HistoryConfig
+	pending []db.MetricSample
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewHistoryRecorder создаёт хранилище истории метрик.
+func NewHistoryRecorder(sqlDB *sql.DB, config models.HistoryConfig) *HistoryRecorder {
+	return &HistoryRecorder{
+		sqlDB:  sqlDB,
+		config: config,
+	}
+}
+
+// Start запускает фоновую запись и агрегацию. Перед запуском досчитываются агрегаты
+// за время, пока агент не работал.
+func (r *HistoryRecorder) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.done = make(chan struct{})
+
+	r.rollup(time.Now(), true)
+	go r.run(ctx)
+}
+
+// Stop останавливает фоновую работу и сохраняет оставшиеся в буфере замеры.
+func (r *HistoryRecorder) Stop() {
+	if r.cancel == nil {
+		return
+	}
+	r.cancel()
+	<-r.done
+}
+
+// Record добавляет замер метрики в буфер. В базу он попадёт при ближайшей записи буфера.
+func (r *HistoryRecorder) Record(metric string, value float64, at time.Time) {
+	r.mu.Lock()
+	r.pending = append(r.pending, db.MetricSample{Metric: metric, TS: at.Unix(), Value: value})
+	r.mu.Unlock()
+}
+
+// Config возвращает текущие сроки хранения.
+func (r *HistoryRecorder) Config() models.HistoryConfig {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.config
+}
+
+// SetConfig меняет сроки хранения. Новые сроки применяются при следующей очистке.
+func (r *HistoryRecorder) SetConfig(config models.HistoryConfig) error {
+	if config.RawRetentionHours < 1 || config.MinuteRetentionDays < 1 || config.HourRetentionDays < 1 {
+		return fmt.Errorf("сроки хранения должны быть положительными")
+	}
+	if config.MinuteRetentionDays > config.HourRetentionDays {
+		return fmt.Errorf("minuteRetentionDays не может превышать hourRetentionDays")
+	}
+
+	r.mu.Lock()
+	r.config = config
+	r.mu.Unlock()
+	return nil
+}
+
+// Query возвращает историю метрики за [from, to). Источник данных выбирается автоматически:
+// самый грубый из тех, что ещё хранятся за весь интервал и не грубее шага.
+// step = 0 означает подбор шага под historyMaxPoints точек.
+func (r *HistoryRecorder) Query(metric string, from, to time.Time, step time.Duration) (models.HistoryResponse, error) {
+	if !from.Before(to) {
+		return models.HistoryResponse{}, ErrInvalidHistoryRange
+	}
+
+	stepSec := int64(step.Seconds())
+	if stepSec <= 0 {
+		stepSec = max(1, int64(to.Sub(from).Seconds())/historyMaxPoints)
+	}
+
+	source := r.pickSource(from, stepSec, time.Now())
+	if source.resolution > 0 {
+		stepSec = alignUp(stepSec, source.resolution)
+	}
+
+	points, err := db.QueryHistory(r.sqlDB, metric, source.resolution, from.Unix(), to.Unix(), stepSec)
+	if err != nil {
+		return models.HistoryResponse{}, err
+	}
+
+	return models.HistoryResponse{
+		Metric:     metric,
+		Resolution: source.name,
+		Step:       stepSec,
+		From:       from.Format(db.TimeLayout),
+		To:         to.Format(db.TimeLayout),
+		Points:     points,
+	}, nil
+}
+
+// pickSource выбирает источник данных для запроса, начинающегося в from, с шагом stepSec.
+func (r *HistoryRecorder) pickSource(from time.Time, stepSec int64, now time.Time) historySource {
+	config := r.Config()
+	sources := []historySource{
+		{name: "raw", resolution: 0, retention: time.Duration(config.RawRetentionHours) * time.Hour},
+		{name: "1m", resolution: db.ResolutionMinute, retention: time.Duration(config.MinuteRetentionDays) * 24 * time.Hour},
+		{name: "1h", resolution: db.ResolutionHour, retention: time.Duration(config.HourRetentionDays) * 24 * time.Hour},
+	}
+
+	best := -1
+	for i, source := range sources {
+		if from.Before(now.Add(-source.retention)) {
+			continue
+		}
+		if best == -1 || source.resolution <= stepSec {
+			best = i
+		}
+	}
+	if best == -1 {
+		best = len(sources) - 1
+	}
+	return sources[best]
+}
+
+func (r *HistoryRecorder) run(ctx context.Context) {
+	defer close(r.done)
+
+	flushTicker := time.NewTicker(historyFlushInterval)
+	defer flushTicker.Stop()
+
+	rollupTicker := time.NewTicker(historyRollupInterval)
+	defer rollupTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.flush()
+			return
+		case <-flushTicker.C:
+			r.flush()
+		case now := <-rollupTicker.C:
+			r.flush()
+			r.rollup(now, false)
+		}
+	}
+}
+
+// flush записывает накопленные замеры в базу.
+func (r *HistoryRecorder) flush() {
+	r.mu.Lock()
+	samples := r.pending
+	r.pending = nil
+	r.mu.Unlock()
+
+	if err := db.InsertMetricSamples(r.sqlDB, samples); err != nil {
+		log.Printf("Ошибка записи истории метрик: %v", err)
+	}
+}
+
+// rollup строит агрегаты по завершённым минутам и часам и удаляет устаревшие данные.
+// Обычно пересчитываются только последние корзины; catchUp пересчитывает всё, что ещё хранится.
+func (r *HistoryRecorder) rollup(now time.Time, catchUp bool) {
+	config := r.Config()
+	rawRetention := time.Duration(config.RawRetentionHours) * time.Hour
+	minuteRetention := time.Duration(config.MinuteRetentionDays) * 24 * time.Hour
+	hourRetention := time.Duration(config.HourRetentionDays) * 24 * time.Hour
+
+	minuteEnd := now.Unix() / db.ResolutionMinute * db.ResolutionMinute
+	hourEnd := now.Unix() / db.ResolutionHour * db.ResolutionHour
+
+	minuteFrom := minuteEnd - 10*db.ResolutionMinute
+	hourFrom := hourEnd - 3*db.ResolutionHour
+	if catchUp {
+		// Границы выравниваются вверх, чтобы не перезаписать частично удалённую первую корзину.
+		minuteFrom = alignUp(now.Add(-rawRetention).Unix(), db.ResolutionMinute)
+		hourFrom = alignUp(now.Add(-minuteRetention).Unix(), db.ResolutionHour)
+	}
+
+	if err := db.RollupSamples(r.sqlDB, minuteFrom, minuteEnd); err != nil {
+		log.Printf("Ошибка агрегации истории: %v", err)
+		return
+	}
+	if err := db.RollupMinutes(r.sqlDB, hourFrom, hourEnd); err != nil {
+		log.Printf("Ошибка агрегации истории: %v", err)
+		return
+	}
+
+	if err := db.PruneHistory(r.sqlDB,
+		now.Add(-rawRetention).Unix(),
+		now.Add(-minuteRetention).Unix(),
+		now.Add(-hourRetention).Unix(),
+	); err != nil {
+		log.Printf("Ошибка очистки истории: %v", err)
+	}
+}
+
+// alignUp округляет Unix-время вверх до границы корзины.
+func alignUp(ts, resolution int64) int64 {
+	return (ts + resolution - 1) / resolution * resolution
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/db"
+	"github.com/RZhurakovskiy/agent/server/getmetrics"
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// sha256Pattern - допустимый вид хэша в правиле: 64 hex-символа в нижнем регистре.
+var sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// integrityQueueSize - сколько исполняемых файлов может ждать хэширования. Если очередь заполнена,
+// остальные файлы ставятся в очередь при следующих снимках процессов.
+const integrityQueueSize = 256
+
+// integrityJob - исполняемый файл процесса, хэш которого ещё не посчитан.
+type integrityJob struct {
+	pid int32
+	exe string
+}
+
+// integrityRuleSet - правила списков, разложенные для быстрой проверки процесса.
+type integrityRuleSet struct {
+	allowHashes map[string]bool
+	denyHashes  map[string]bool
+	allowPaths  []string
+	denyPaths   []string
+}
+
+// IntegrityChecker проверяет исполняемые файлы процессов по спискам разрешённых и запрещённых
+// хэшей и путей из таблицы integrity_rules. О каждом новом неизвестном хэше записывается событие
+// в таблицу integrity_events. Файлы хэшируются в фоне (Start, VerifyCached).
+type IntegrityChecker struct {
+	sqlDB    *sql.DB
+	hasher   *getmetrics.ExeHasher
+	mu       sync.Mutex
+	rules    integrityRuleSet
+	reported map[string]bool
+	jobs     chan integrityJob
+	pending  map[string]bool // Файлы в очереди хэширования, по пути
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewIntegrityChecker создаёт проверку целостности и загружает правила и уже известные хэши из базы.
+func NewIntegrityChecker(sqlDB *sql.DB) (*IntegrityChecker, error) {
+	c := &IntegrityChecker{
+		sqlDB:   sqlDB,
+		hasher:  getmetrics.NewExeHasher(),
+		jobs:    make(chan integrityJob, integrityQueueSize),
+		pending: make(map[string]bool),
+	}
+	if err := c.reload(); err != nil {
+		return nil, err
+	}
+
+	reported, err := db.IntegrityEventHashes(sqlDB)
+	if err != nil {
+		return nil, err
+	}
+	c.reported = reported
+	return c, nil
+}
+
+// Start запускает фоновое хэширование файлов, поставленных в очередь VerifyCached.
+func (c *IntegrityChecker) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.done = make(chan struct{})
+	go c.run(ctx)
+}
+
+// Stop останавливает фоновое хэширование. Файлы, оставшиеся в очереди, не хэшируются.
+func (c *IntegrityChecker) Stop() {
+	if c == nil || c.cancel == nil {
+		return
+	}
+	c.cancel()
+	<-c.done
+}
+
+// Rules возвращает все правила списков.
+func (c *IntegrityChecker) Rules() ([]models.IntegrityRule, error) {
+	return db.ListIntegrityRules(c.sqlDB)
+}
+
+// AddRule проверяет и сохраняет правило. Хэш приводится к нижнему регистру, путь должен быть абсолютным.
+func (c *IntegrityChecker) AddRule(rule models.IntegrityRule) (models.IntegrityRule, error) {
+	rule.Value = strings.TrimSpace(rule.Value)
+	rule.Comment = strings.TrimSpace(rule.Comment)
+
+	if rule.List != models.IntegrityListAllow && rule.List != models.IntegrityListDeny {
+		return rule, fmt.Errorf("поле 'list' должно быть \"allow\" или \"deny\"")
+	}
+	switch rule.Kind {
+	case models.IntegrityKindHash:
+		rule.Value = strings.ToLower(rule.Value)
+		if !sha256Pattern.MatchString(rule.Value) {
+			return rule, fmt.Errorf("поле 'value' должно содержать SHA-256 в hex (64 символа)")
+		}
+	case models.IntegrityKindPath:
+		if !filepath.IsAbs(rule.Value) && !strings.HasPrefix(rule.Value, "/") {
+			return rule, fmt.Errorf("поле 'value' должно содержать абсолютный путь")
+		}
+	default:
+		return rule, fmt.Errorf("поле 'kind' должно быть \"hash\" или \"path\"")
+	}
+
+	rule, err := db.InsertIntegrityRule(c.sqlDB, rule, time.Now())
+	if err != nil {
+		return rule, err
+	}
+	return rule, c.reload()
+}
+
+// DeleteRule удаляет правило по id.
+func (c *IntegrityChecker) DeleteRule(id int64) error {
+	if err := db.DeleteIntegrityRule(c.sqlDB, id); err != nil {
+		return err
+	}
+	return c.reload()
+}
+
+// HashProcess возвращает SHA-256 и путь исполняемого файла работающего процесса
+// (хэш берётся из кэша, если файл не менялся).
+func (c *IntegrityChecker) HashProcess(pid int32) (string, string, error) {
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return "", "", fmt.Errorf("процесс PID=%d не найден: %w", pid, err)
+	}
+	exe, err := p.Exe()
+	if err != nil {
+		return "", "", fmt.Errorf("не удалось получить исполняемый файл PID=%d: %w", pid, err)
+	}
+	hash, err := c.hasher.Hash(pid, exe)
+	return hash, exe, err
+}
+
+// VerifyCached заполняет SHA256 и Verdict у процессов снимка и записывает события о хэшах, которые
+// встретились впервые и не попали ни в один список. Файлы не читаются, хэши берутся только из кэша:
+// файлы без хэша ставятся в очередь фонового хэширования и до его окончания получают вердикт
+// только по правилам путей; хэш и вердикт по нему появляются в одном из следующих снимков.
+// У nil-проверки метод ничего не делает.
+func (c *IntegrityChecker) VerifyCached(procs []models.ProcessInfo, now time.Time) {
+	if c == nil {
+		return
+	}
+
+	var misses []integrityJob
+	for i := range procs {
+		if procs[i].Exe == "" {
+			continue
+		}
+		if hash, ok := c.hasher.Cached(procs[i].PID, procs[i].Exe); ok {
+			procs[i].SHA256 = hash
+		} else {
+			misses = append(misses, integrityJob{pid: procs[i].PID, exe: procs[i].Exe})
+		}
+	}
+	c.hasher.Sweep()
+	c.enqueue(misses)
+	c.applyVerdicts(procs, now)
+}
+
+// enqueue ставит файлы в очередь хэширования, пропуская уже стоящие в ней. Не блокируется:
+// при заполненной очереди оставшиеся файлы будут поставлены следующим снимком.
+func (c *IntegrityChecker) enqueue(jobs []integrityJob) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, job := range jobs {
+		if c.pending[job.exe] {
+			continue
+		}
+		select {
+		case c.jobs <- job:
+			c.pending[job.exe] = true
+		default:
+			return
+		}
+	}
+}
+
+// run хэширует файлы из очереди. Ошибки не журналируются: процесс мог завершиться,
+// а файл будет поставлен в очередь снова, если процесс ещё работает.
+func (c *IntegrityChecker) run(ctx context.Context) {
+	defer close(c.done)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-c.jobs:
+			c.hasher.Hash(job.pid, job.exe)
+			c.mu.Lock()
+			delete(c.pending, job.exe)
+			c.mu.Unlock()
+		}
+	}
+}
+
+// applyVerdicts проставляет вердикты процессам с уже известными хэшами и записывает события
+// о неизвестных хэшах, которые встретились впервые.
+func (c *IntegrityChecker) applyVerdicts(procs []models.ProcessInfo, now time.Time) {
+	var candidates []models.IntegrityEvent
+
+	c.mu.Lock()
+	for i := range procs {
+		p := &procs[i]
+		p.Verdict = c.rules.verdict(p.SHA256, strings.TrimSuffix(p.Exe, " (deleted)"))
+		if p.Verdict != models.IntegrityUnknown || p.SHA256 == "" || c.reported[p.SHA256] {
+			continue
+		}
+		c.reported[p.SHA256] = true
+		candidates = append(candidates, models.IntegrityEvent{
+			SHA256:   p.SHA256,
+			Exe:      p.Exe,
+			PID:      p.PID,
+			Name:     p.Name,
+			Username: p.Username,
+		})
+	}
+	c.mu.Unlock()
+
+	inserted := 0
+	for i := range candidates {
+		ok, err := db.InsertIntegrityEvent(c.sqlDB, &candidates[i], now)
+		if err != nil {
+			log.Printf("Ошибка записи события целостности: %v", err)
+			continue
+		}
+		if ok {
+			inserted++
+		}
+	}
+	if inserted > 0 {
+		log.Printf("Обнаружено новых неизвестных исполняемых файлов: %d", inserted)
+	}
+}
+
+// reload перечитывает правила из базы после изменения списков.
+func (c *IntegrityChecker) reload() error {
+	rules, err := db.ListIntegrityRules(c.sqlDB)
+	if err != nil {
+		return err
+	}
+
+	set := integrityRuleSet{allowHashes: make(map[string]bool), denyHashes: make(map[string]bool)}
+	for _, rule := range rules {
+		switch {
+		case rule.List == models.IntegrityListAllow && rule.Kind == models.IntegrityKindHash:
+			set.allowHashes[rule.Value] = true
+		case rule.List == models.IntegrityListDeny && rule.Kind == models.IntegrityKindHash:
+			set.denyHashes[rule.Value] = true
+		case rule.List == models.IntegrityListAllow && rule.Kind == models.IntegrityKindPath:
+			set.allowPaths = append(set.allowPaths, rule.Value)
+		case rule.List == models.IntegrityListDeny && rule.Kind == models.IntegrityKindPath:
+			set.denyPaths = append(set.denyPaths, rule.Value)
+		}
+	}
+
+	c.mu.Lock()
+	c.rules = set
+	c.mu.Unlock()
+	return nil
+}
+
+// verdict выносит вердикт по хэшу и пути исполняемого файла. Запрет проверяется раньше разрешения.
+// Пустой результат - хэш не посчитан и путь не подходит ни под одно правило.
+func (s integrityRuleSet) verdict(hash, exe string) string {
+	switch {
+	case s.denyHashes[hash] || matchIntegrityPath(exe, s.denyPaths):
+		return models.IntegrityDenied
+	case s.allowHashes[hash] || matchIntegrityPath(exe, s.allowPaths):
+		return models.IntegrityKnown
+	case hash != "":
+		return models.IntegrityUnknown
+	default:
+		return ""
+	}
+}
+
+// matchIntegrityPath сообщает, подходит ли путь под одно из правил: совпадает с ним
+// или лежит внутри каталога, если правило заканчивается разделителем пути.
+func matchIntegrityPath(exe string, rules []string) bool {
+	if exe == "" {
+		return false
+	}
+	for _, rule := range rules {
+		if exe == rule || (strings.HasSuffix(rule, "/") || strings.HasSuffix(rule, `\`)) && strings.HasPrefix(exe, rule) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/db"
+	"github.com/RZhurakovskiy/agent/server/models"
+)
+
+// processKey однозначно идентифицирует процесс: PID переиспользуется, время создания - нет.
+type processKey struct {
+	pid        int32
+	createTime int64
+}
+
+// LifecycleTracker сравнивает последовательные снимки процессов и порождает события
+// started/exited, которые сохраняются в таблицу process_events.
+type LifecycleTracker struct {
+	sqlDB        *sql.DB
+	mu           sync.Mutex
+	known        map[processKey]models.ProcessInfo
+	lastSnapshot time.Time
+	primed       bool
+}
+
+// NewLifecycleTracker создаёт трекер жизненного цикла процессов.
+func NewLifecycleTracker(sqlDB *sql.DB) *LifecycleTracker {
+	return &LifecycleTracker{sqlDB: sqlDB}
+}
+
+// Observe сравнивает снимок с предыдущим, сохраняет и возвращает обнаруженные события.
+// Первый снимок после создания или Reset только запоминается: событий по нему нет.
+func (t *LifecycleTracker) Observe(procs []models.ProcessInfo, now time.Time) []models.ProcessEvent {
+	current := make(map[processKey]models.ProcessInfo, len(procs))
+	for _, p := range procs {
+		current[processKey{pid: p.PID, createTime: p.CreateTime}] = p
+	}
+
+	t.mu.Lock()
+
+	if !t.primed {
+		t.known = current
+		t.lastSnapshot = now
+		t.primed = true
+		t.mu.Unlock()
+		return nil
+	}
+
+	var events []models.ProcessEvent
+	for key, p := range current {
+		if _, ok := t.known[key]; !ok {
+			events = append(events, newProcessEvent(models.ProcessEventStarted, p, 0))
+		}
+	}
+	for key, p := range t.known {
+		if _, ok := current[key]; !ok {
+			events = append(events, newProcessEvent(models.ProcessEventExited, p, observedLifetime(p, t.lastSnapshot)))
+		}
+	}
+
+	t.known = current
+	t.lastSnapshot = now
+
+	t.mu.Unlock()
+
+	if err := db.InsertProcessEvents(t.sqlDB, now, events); err != nil {
+		log.Printf("Ошибка записи событий процессов: %v", err)
+	}
+
+	timestamp := now.Format(db.TimeLayout)
+	for i := range events {
+		events[i].Timestamp = timestamp
+	}
+	return events
+}
+
+// Reset забывает предыдущий снимок. Вызывается при выключении мониторинга,
+// чтобы после паузы не получить события за всё время простоя.
+func (t *LifecycleTracker) Reset() {
+	t.mu.Lock()
+	t.known = nil
+	t.primed = false
+	t.mu.Unlock()
+}
+
+func newProcessEvent(eventType string, p models.ProcessInfo, lifetimeSec int64) models.ProcessEvent {
+	return models.ProcessEvent{
+		Type:        eventType,
+		PID:         p.PID,
+		CreateTime:  p.CreateTime,
+		Name:        p.Name,
+		Exe:         p.Exe,
+		Cmdline:     p.Cmdline,
+		Username:    p.Username,
+		ParentPID:   p.ParentPID,
+		LifetimeSec: lifetimeSec,
+	}
+}
+
+// observedLifetime - время от запуска процесса до последнего снимка, в котором он был виден.
+func observedLifetime(p models.ProcessInfo, lastSeen time.Time) int64 {
+	if p.CreateTime <= 0 {
+		return 0
+	}
+	return max(0, int64(lastSeen.Sub(time.UnixMilli(p.CreateTime)).Seconds()))
+}
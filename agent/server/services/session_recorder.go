@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/db"
+	"github.com/RZhurakovskiy/agent/server/getmetrics"
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+// Допустимый интервал опроса процессов в сессии записи, в секундах.
+const (
+	minSessionIntervalSec = 1
+	maxSessionIntervalSec = 3600
+)
+
+// maxSessionSamples - сколько строк session_samples может записать одна сессия. Низкий порог CPU
+// с коротким интервалом даёт строку на каждый процесс каждую секунду; после предела сессия остаётся
+// активной, но замеры больше не сохраняются.
+const maxSessionSamples = 500000
+
+// ErrSessionActive возвращается при попытке запустить вторую сессию записи.
+var ErrSessionActive = errors.New("уже идёт запись другой сессии")
+
+// activeSession - запущенная сессия и функция остановки её горутины.
+type activeSession struct {
+	session models.Session
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// SessionRecorder управляет сессиями записи: по команде запускает опрос процессов
+// с заданным интервалом и сохраняет процессы выше порога CPU в SQLite.
+// Одновременно может быть активна только одна сессия.
+type SessionRecorder struct {
+	sqlDB  *sql.DB
+	mu     sync.Mutex
+	active *activeSession
+}
+
+// NewSessionRecorder создаёт менеджер сессий. Сессии, оставшиеся открытыми
+// после аварийного завершения агента, помечаются остановленными.
+func NewSessionRecorder(sqlDB *sql.DB) (*SessionRecorder, error) {
+	if err := db.CloseDanglingSessions(sqlDB, time.Now()); err != nil {
+		return nil, err
+	}
+	return &SessionRecorder{sqlDB: sqlDB}, nil
+}
+
+// Start запускает новую сессию записи.
+func (r *SessionRecorder) Start(name string, cpuThreshold float64, intervalSec int) (models.Session, error) {
+	if cpuThreshold < 0 {
+		return models.Session{}, fmt.Errorf("cpuThreshold не может быть отрицательным")
+	}
+	if intervalSec < minSessionIntervalSec || intervalSec > maxSessionIntervalSec {
+		return models.Session{}, fmt.Errorf("intervalSec должен быть от %d до %d", minSessionIntervalSec, maxSessionIntervalSec)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.active != nil {
+		return models.Session{}, ErrSessionActive
+	}
+
+	session, err := db.CreateSession(r.sqlDB, name, cpuThreshold, intervalSec, time.Now())
+	if err != nil {
+		return models.Session{}, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.active = &activeSession{session: session, cancel: cancel, done: make(chan struct{})}
+	go r.record(ctx, session, getmetrics.NewProcessCollector(), r.active.done)
+
+	log.Printf("Запущена сессия записи %d (%q): CPU >= %.1f%%, интервал %d с", session.ID, name, cpuThreshold, intervalSec)
+	return session, nil
+}
+
+// Stop останавливает активную сессию с указанным id.
+func (r *SessionRecorder) Stop(id int64) (models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.active == nil || r.active.session.ID != id {
+		session, err := db.GetSession(r.sqlDB, id)
+		if err != nil {
+			return models.Session{}, err
+		}
+		return session, fmt.Errorf("сессия %d не активна", id)
+	}
+
+	if err := r.stopActive(); err != nil {
+		return models.Session{}, err
+	}
+	return db.GetSession(r.sqlDB, id)
+}
+
+// StopAll останавливает активную сессию, если она есть. Вызывается при остановке сервера.
+func (r *SessionRecorder) StopAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.active == nil {
+		return
+	}
+	if err := r.stopActive(); err != nil {
+		log.Printf("Ошибка остановки сессии: %v", err)
+	}
+}
+
+// stopActive дожидается завершения горутины записи и фиксирует время остановки. Вызывается под r.mu.
+func (r *SessionRecorder) stopActive() error {
+	r.active.cancel()
+	<-r.active.done
+
+	id := r.active.session.ID
+	r.active = nil
+
+	if err := db.StopSession(r.sqlDB, id, time.Now()); err != nil {
+		return err
+	}
+	log.Printf("Сессия записи %d остановлена", id)
+	return nil
+}
+
+// record опрашивает процессы с интервалом сессии до отмены контекста или до записи maxSessionSamples строк.
+// У сессии свой сборщик, чтобы CPU% считался ровно за интервал сессии.
+func (r *SessionRecorder) record(ctx context.Context, session models.Session, collector *getmetrics.ProcessCollector, done chan struct{}) {
+	defer close(done)
+
+	// Первый снимок только задаёт точку отсчёта CPU-времени.
+	if _, err := collector.Collect([]net.ConnectionStat{}); err != nil {
+		log.Printf("Ошибка получения процессов для сессии %d: %v", session.ID, err)
+	}
+
+	ticker := time.NewTicker(time.Duration(session.IntervalSec) * time.Second)
+	defer ticker.Stop()
+
+	stored := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			stored += r.sample(session, collector, now, maxSessionSamples-stored)
+			if stored >= maxSessionSamples {
+				log.Printf("Сессия %d записала %d замеров - предел достигнут, дальнейшие замеры не сохраняются", session.ID, stored)
+				return
+			}
+		}
+	}
+}
+
+// sample снимает список процессов и сохраняет не больше limit из тех, что не ниже порога CPU сессии.
+// Возвращает число сохранённых строк.
+func (r *SessionRecorder) sample(session models.Session, collector *getmetrics.ProcessCollector, now time.Time, limit int) int {
+	procs, err := collector.Collect([]net.ConnectionStat{})
+	if err != nil {
+		log.Printf("Ошибка получения процессов для сессии %d: %v", session.ID, err)
+		return 0
+	}
+
+	matched := make([]models.ProcessInfo, 0, len(procs))
+	for _, p := range procs {
+		if p.CPUPercent >= session.CPUThreshold {
+			matched = append(matched, p)
+		}
+	}
+	matched = matched[:min(len(matched), limit)]
+
+	if err := db.InsertSessionSamples(r.sqlDB, session.ID, now, matched); err != nil {
+		log.Printf("Ошибка записи замеров сессии %d: %v", session.ID, err)
+		return 0
+	}
+	return len(matched)
+}
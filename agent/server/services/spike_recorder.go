@@ -0,0 +1,195 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/db"
+	"github.com/RZhurakovskiy/agent/server/models"
+)
+
+// DefaultSpikeConfig - пороги детектора всплесков по умолчанию.
+var DefaultSpikeConfig = models.SpikeConfig{
+	CPUThreshold:   80,
+	RSSThresholdMB: 1024,
+	MinDurationSec: 0,
+}
+
+// spikeKey идентифицирует эпизод: PID может быть переиспользован, поэтому учитывается время создания процесса.
+type spikeKey struct {
+	pid        int32
+	createTime int64
+	reason     string
+}
+
+// spikeEpisode - открытый (ещё не записанный) эпизод всплеска.
+type spikeEpisode struct {
+	startedAt time.Time
+	lastSeen  time.Time
+	name      string
+	peakCPU   float64
+	peakRSS   uint64
+	peakVMS   uint64
+}
+
+// finishedSpike - закрытый эпизод, ожидающий записи в базу.
+type finishedSpike struct {
+	models.Spike
+	startedAt time.Time
+}
+
+// SpikeRecorder отслеживает превышение порогов CPU и RSS процессами
+// и записывает в таблицу spikes по одной строке на каждый завершившийся эпизод.
+type SpikeRecorder struct {
+	sqlDB  *sql.DB
+	mu     sync.Mutex
+	config models.SpikeConfig
+	active map[spikeKey]*spikeEpisode
+	// lastObserved и interval - время последнего снимка и промежуток между двумя последними снимками:
+	// эпизод, закрытый без снимка ниже порога, продлевается на один интервал.
+	lastObserved time.Time
+	interval     time.Duration
+}
+
+// NewSpikeRecorder создаёт детектор всплесков, пишущий в переданную базу.
+func NewSpikeRecorder(sqlDB *sql.DB, config models.SpikeConfig) *SpikeRecorder {
+	return &SpikeRecorder{
+		sqlDB:  sqlDB,
+		config: config,
+		active: make(map[spikeKey]*spikeEpisode),
+	}
+}
+
+// Config возвращает текущие пороги.
+func (r *SpikeRecorder) Config() models.SpikeConfig {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.config
+}
+
+// SetConfig меняет пороги. Открытые эпизоды продолжаются и закроются уже по новым порогам.
+func (r *SpikeRecorder) SetConfig(config models.SpikeConfig) error {
+	if config.CPUThreshold < 0 {
+		return fmt.Errorf("cpuThreshold не может быть отрицательным")
+	}
+	if config.MinDurationSec < 0 {
+		return fmt.Errorf("minDurationSec не может быть отрицательным")
+	}
+
+	r.mu.Lock()
+	r.config = config
+	r.mu.Unlock()
+	return nil
+}
+
+// Observe обрабатывает очередной снимок процессов: открывает эпизоды для процессов
+// выше порога и закрывает эпизоды процессов, которые опустились ниже порога или завершились.
+// Длительность закрытого эпизода считается до этого снимка - первого, где превышения уже нет.
+func (r *SpikeRecorder) Observe(procs []models.ProcessInfo, now time.Time) {
+	r.mu.Lock()
+
+	if !r.lastObserved.IsZero() && now.After(r.lastObserved) {
+		r.interval = now.Sub(r.lastObserved)
+	}
+	r.lastObserved = now
+
+	rssThreshold := r.config.RSSThresholdMB * 1024 * 1024
+	seen := make(map[spikeKey]bool, len(r.active))
+
+	for _, p := range procs {
+		cpuKey := spikeKey{pid: p.PID, createTime: p.CreateTime, reason: models.SpikeReasonCPU}
+		if r.config.CPUThreshold > 0 && p.CPUPercent > r.config.CPUThreshold {
+			r.track(cpuKey, p, now)
+			seen[cpuKey] = true
+		}
+
+		rssKey := spikeKey{pid: p.PID, createTime: p.CreateTime, reason: models.SpikeReasonRSS}
+		if rssThreshold > 0 && p.MemoryRSS > rssThreshold {
+			r.track(rssKey, p, now)
+			seen[rssKey] = true
+		}
+	}
+
+	var finished []finishedSpike
+	for key, episode := range r.active {
+		if seen[key] {
+			continue
+		}
+		delete(r.active, key)
+		if spike, ok := r.finish(key, episode, now); ok {
+			finished = append(finished, spike)
+		}
+	}
+
+	r.mu.Unlock()
+
+	r.save(finished)
+}
+
+// Flush закрывает все открытые эпизоды. Вызывается при выключении мониторинга и остановке сервера.
+// Снимка ниже порога у таких эпизодов нет, поэтому они длятся до последнего снимка плюс один интервал.
+func (r *SpikeRecorder) Flush() {
+	r.mu.Lock()
+
+	var finished []finishedSpike
+	for key, episode := range r.active {
+		delete(r.active, key)
+		if spike, ok := r.finish(key, episode, episode.lastSeen.Add(r.interval)); ok {
+			finished = append(finished, spike)
+		}
+	}
+
+	r.mu.Unlock()
+
+	r.save(finished)
+}
+
+// track открывает эпизод или обновляет пиковые значения уже открытого. Вызывается под r.mu.
+func (r *SpikeRecorder) track(key spikeKey, p models.ProcessInfo, now time.Time) {
+	episode, ok := r.active[key]
+	if !ok {
+		episode = &spikeEpisode{startedAt: now, name: p.Name}
+		r.active[key] = episode
+	}
+
+	episode.lastSeen = now
+	episode.peakCPU = max(episode.peakCPU, p.CPUPercent)
+	episode.peakRSS = max(episode.peakRSS, p.MemoryRSS)
+	episode.peakVMS = max(episode.peakVMS, p.MemoryVMS)
+}
+
+// finish превращает эпизод, закончившийся в endedAt, в строку таблицы. Эпизоды короче
+// MinDurationSec отбрасываются. Вызывается под r.mu.
+func (r *SpikeRecorder) finish(key spikeKey, episode *spikeEpisode, endedAt time.Time) (finishedSpike, bool) {
+	duration := int64(endedAt.Sub(episode.startedAt).Seconds())
+	if duration < r.config.MinDurationSec {
+		return finishedSpike{}, false
+	}
+
+	return finishedSpike{
+		Spike: models.Spike{
+			PID:         key.pid,
+			Name:        episode.name,
+			CPUPercent:  episode.peakCPU,
+			MemoryRSS:   episode.peakRSS,
+			MemoryVMS:   episode.peakVMS,
+			DurationSec: duration,
+			Reason:      key.reason,
+		},
+		startedAt: episode.startedAt,
+	}, true
+}
+
+// save записывает закрытые эпизоды в базу вне блокировки r.mu.
+func (r *SpikeRecorder) save(spikes []finishedSpike) {
+	for _, spike := range spikes {
+		if err := db.InsertSpike(r.sqlDB, spike.Spike, spike.startedAt); err != nil {
+			log.Printf("Ошибка записи всплеска: %v", err)
+			continue
+		}
+		log.Printf("Записан всплеск %s: PID=%d (%s), длительность %d с", spike.Reason, spike.PID, spike.Name, spike.DurationSec)
+	}
+}
@@ -0,0 +1,189 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/getmetrics"
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/gorilla/websocket"
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+var (
+	// connectionsCache - кэш TCP- и UDP-соединений, защищён cacheMutex.
+	connectionsCache []models.Connection
+	// connectionsTimestamp - время снимка connectionsCache.
+	connectionsTimestamp string
+)
+
+// connectionsView - представление /ws/connections, выбранное клиентом при подключении.
+type connectionsView struct {
+	list   bool // Список соединений вместо сводки
+	filter getmetrics.ConnectionFilter
+	limit  int
+}
+
+// parseConnectionsView разбирает фильтр соединений (параметры /api/connections), mode=list и limit.
+func parseConnectionsView(query url.Values) (connectionsView, error) {
+	filter, err := getmetrics.ParseConnectionFilter(query)
+	if err != nil {
+		return connectionsView{}, err
+	}
+	view := connectionsView{
+		list:   query.Get("mode") == "list",
+		filter: filter,
+		limit:  getmetrics.DefaultConnectionsSummaryLimit,
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			return view, fmt.Errorf("некорректное значение параметра limit: %q", value)
+		}
+		view.limit = limit
+	}
+	return view, nil
+}
+
+// apply фильтрует соединения и возвращает их список или сводку по ним.
+func (view connectionsView) apply(conns []models.Connection, timestamp string) any {
+	conns = getmetrics.FilterConnections(conns, view.filter)
+	if view.list {
+		return conns
+	}
+	summary := getmetrics.SummarizeConnections(conns, view.limit)
+	summary.Timestamp = timestamp
+	return summary
+}
+
+// updateConnections обновляет кэш соединений из сокетов, снятых вместе с процессами (каждые 5 секунд).
+// Имена процессов берутся из того же снимка процессов.
+func updateConnections(allConnections []net.ConnectionStat, procs []models.ProcessInfo) {
+	names := make(map[int32]string, len(procs))
+	for _, p := range procs {
+		names[p.PID] = p.Name
+	}
+	conns := getmetrics.ConvertConnections(allConnections, names)
+
+	cacheMutex.Lock()
+	connectionsCache = conns
+	connectionsTimestamp = time.Now().Format("2006-01-02 15:04:05")
+	cacheMutex.Unlock()
+}
+
+// resetConnections очищает кэш соединений при выключении мониторинга: после повторного включения
+// до первого обновления клиенты получают "Данные собираются...", а не таблицу до выключения.
+func resetConnections() {
+	cacheMutex.Lock()
+	connectionsCache = nil
+	connectionsTimestamp = ""
+	cacheMutex.Unlock()
+}
+
+// ConnectionsSnapshot возвращает последний снимок соединений из кэша.
+//
+// Возвращает:
+//   - []models.Connection: список соединений
+//   - string: время снимка
+//   - bool: false, если мониторинг выключен или данные ещё не собраны
+func ConnectionsSnapshot() ([]models.Connection, string, bool) {
+	if !GetMonitoringEnabled() {
+		return nil, "", false
+	}
+
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+	return connectionsCache, connectionsTimestamp, connectionsTimestamp != ""
+}
+
+// StreamConnections устанавливает WebSocket-соединение и начинает потоковую передачу
+// сводки по соединениям клиенту. Данные отправляются каждые 5 секунд из кэша.
+// Фильтры задаются теми же параметрами, что и у /api/connections; mode=list отправляет
+// список соединений вместо сводки, limit ограничивает строки сводки.
+//
+// Параметры:
+//   - w: HTTP ResponseWriter для обновления соединения до WebSocket
+//   - r: HTTP Request с информацией о клиенте
+func StreamConnections(w http.ResponseWriter, r *http.Request) {
+	view, err := parseConnectionsView(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Ошибка обновления соединения до WebSocket (соединения): %v", err)
+		return
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	conn.SetWriteDeadline(time.Now().Add(30 * time.Second))
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		return nil
+	})
+
+	if err := writeConnections(conn, view); err != nil {
+		log.Printf("Ошибка отправки первого сообщения соединений: %v", err)
+		return
+	}
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := writeConnections(conn, view); err != nil {
+			return
+		}
+	}
+}
+
+// writeConnections отправляет сводку или список соединений из кэша через WebSocket-соединение.
+// Проверяет состояние мониторинга перед отправкой данных.
+//
+// Параметры:
+//   - conn: активное WebSocket-соединение
+//   - view: представление, выбранное клиентом при подключении
+//
+// Возвращает:
+//   - error: ошибка при сериализации или отправке данных
+func writeConnections(conn *websocket.Conn, view connectionsView) error {
+
+	monitoringMutex.RLock()
+	enabled := monitoringEnabled
+	monitoringMutex.RUnlock()
+
+	if !enabled {
+
+		statusMsg := `{"monitoringEnabled":false,"message":"Мониторинг выключен"}`
+		conn.SetWriteDeadline(time.Now().Add(30 * time.Second))
+		return conn.WriteMessage(websocket.TextMessage, []byte(statusMsg))
+	}
+
+	cacheMutex.RLock()
+	conns := connectionsCache
+	timestamp := connectionsTimestamp
+	cacheMutex.RUnlock()
+
+	if timestamp == "" {
+		statusMsg := `{"monitoringEnabled":true,"message":"Данные собираются...","timestamp":"` + time.Now().Format("2006-01-02 15:04:05") + `"}`
+		conn.SetWriteDeadline(time.Now().Add(30 * time.Second))
+		return conn.WriteMessage(websocket.TextMessage, []byte(statusMsg))
+	}
+
+	b, err := json.Marshal(view.apply(conns, timestamp))
+	if err != nil {
+		log.Printf("Ошибка сериализации соединений: %v", err)
+		return conn.WriteMessage(websocket.TextMessage, []byte(`{"error":"Ошибка сериализации данных"}`))
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(30 * time.Second))
+	return conn.WriteMessage(websocket.TextMessage, b)
+}
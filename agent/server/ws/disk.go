@@ -0,0 +1,121 @@
+package ws
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/getmetrics"
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/gorilla/websocket"
+)
+
+// diskCache - кэш метрик дисков, защищён cacheMutex.
+var diskCache models.DiskStats
+
+// updateDiskMetrics обновляет кэш метрик дисков (каждые 5 секунд).
+func updateDiskMetrics(collector *getmetrics.DiskCollector) {
+	stats, err := collector.Collect()
+	if err != nil {
+		log.Printf("Ошибка обновления кэша дисков: %v", err)
+		return
+	}
+	stats.Timestamp = time.Now().Format("2006-01-02 15:04:05")
+
+	cacheMutex.Lock()
+	diskCache = stats
+	cacheMutex.Unlock()
+}
+
+// DiskSnapshot возвращает последний снимок дисков из кэша.
+//
+// Возвращает:
+//   - models.DiskStats: снимок дисков
+//   - bool: false, если мониторинг выключен или данные ещё не собраны
+func DiskSnapshot() (models.DiskStats, bool) {
+	if !GetMonitoringEnabled() {
+		return models.DiskStats{}, false
+	}
+
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+	return diskCache, diskCache.Timestamp != ""
+}
+
+// StreamDisk устанавливает WebSocket-соединение и начинает потоковую передачу
+// метрик дисков клиенту. Данные отправляются каждые 5 секунд из кэша.
+//
+// Параметры:
+//   - w: HTTP ResponseWriter для обновления соединения до WebSocket
+//   - r: HTTP Request с информацией о клиенте
+func StreamDisk(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Ошибка обновления соединения до WebSocket (диски): %v", err)
+		return
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		return nil
+	})
+
+	if err := writeDisk(conn); err != nil {
+		log.Printf("Ошибка отправки первого сообщения дисков: %v", err)
+		return
+	}
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := writeDisk(conn); err != nil {
+			return
+		}
+	}
+}
+
+// writeDisk отправляет кэшированные метрики дисков через WebSocket-соединение.
+// Проверяет состояние мониторинга перед отправкой данных.
+//
+// Параметры:
+//   - conn: активное WebSocket-соединение
+//
+// Возвращает:
+//   - error: ошибка при сериализации или отправке данных
+func writeDisk(conn *websocket.Conn) error {
+
+	monitoringMutex.RLock()
+	enabled := monitoringEnabled
+	monitoringMutex.RUnlock()
+
+	if !enabled {
+
+		statusMsg := `{"monitoringEnabled":false,"message":"Мониторинг выключен"}`
+		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		return conn.WriteMessage(websocket.TextMessage, []byte(statusMsg))
+	}
+
+	cacheMutex.RLock()
+	data := diskCache
+	cacheMutex.RUnlock()
+
+	if data.Timestamp == "" {
+		statusMsg := `{"monitoringEnabled":true,"message":"Данные собираются...","mounts":[],"devices":[],"timestamp":"` + time.Now().Format("2006-01-02 15:04:05") + `"}`
+		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		return conn.WriteMessage(websocket.TextMessage, []byte(statusMsg))
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Ошибка сериализации метрик дисков: %v", err)
+		return conn.WriteMessage(websocket.TextMessage, []byte(`{"error":"Ошибка сериализации данных"}`))
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	return conn.WriteMessage(websocket.TextMessage, b)
+}
@@ -0,0 +1,207 @@
+package ws
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// hubQueueSize - длина очереди отправки одного клиента. Сообщения хаба - снимки состояния,
+// поэтому отстающему клиенту достаточно последних из них.
+const hubQueueSize = 4
+
+var (
+	// cpuHub рассылает метрики CPU клиентам /ws/cpu после каждого обновления кэша.
+	cpuHub = newHub(`{"monitoringEnabled":false,"message":"Мониторинг выключен"}`)
+	// memoryHub рассылает метрики памяти клиентам /ws/memory.
+	memoryHub = newHub(`{"monitoringEnabled":false,"message":"Мониторинг выключен"}`)
+	// processesHub рассылает полный список процессов клиентам /ws/processes без выборки, контейнера и дерева.
+	processesHub = newHub(`{"monitoringEnabled":false,"message":"Мониторинг выключен"}`)
+)
+
+// hub рассылает одно и то же сообщение всем подписчикам. Сообщение сериализуется и кадрируется
+// один раз (websocket.PreparedMessage), а подписчикам передаётся через ограниченные очереди:
+// публикация не ждёт медленных клиентов - если очередь клиента заполнена, самое старое сообщение
+// в ней заменяется новым.
+type hub struct {
+	mu      sync.Mutex
+	clients map[*hubClient]struct{}
+	last    *websocket.PreparedMessage // Последнее сообщение: новый подписчик получает его сразу
+}
+
+// hubClient - подписка одного клиента на хаб.
+type hubClient struct {
+	queue   chan *websocket.PreparedMessage
+	dropped atomic.Uint64 // Число сообщений, вытесненных из очереди более новыми
+}
+
+// newHub создаёт хаб; initial отправляется подписчикам, пока ничего не опубликовано.
+func newHub(initial string) *hub {
+	h := &hub{clients: make(map[*hubClient]struct{})}
+	h.publishRaw([]byte(initial))
+	return h
+}
+
+// subscribe регистрирует клиента; последнее опубликованное сообщение уже лежит в его очереди.
+func (h *hub) subscribe() *hubClient {
+	client := &hubClient{queue: make(chan *websocket.PreparedMessage, hubQueueSize)}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clients[client] = struct{}{}
+	if h.last != nil {
+		client.queue <- h.last
+	}
+	return client
+}
+
+// unsubscribe удаляет клиента из хаба.
+func (h *hub) unsubscribe(client *hubClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.clients, client)
+}
+
+// publish сериализует v один раз и рассылает всем подписчикам.
+func (h *hub) publish(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return h.publishRaw(b)
+}
+
+// publishIfMonitoring сериализует v и рассылает, только если мониторинг включён. Проверка и рассылка
+// выполняются под monitoringMutex: SetMonitoringEnabled рассылает статус под той же блокировкой, поэтому
+// данные уже остановленного мониторинга не могут прийти клиентам после "Мониторинг выключен".
+func (h *hub) publishIfMonitoring(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	monitoringMutex.RLock()
+	defer monitoringMutex.RUnlock()
+	if !monitoringEnabled {
+		return nil
+	}
+	return h.publishRaw(b)
+}
+
+// publishRaw рассылает готовое JSON-сообщение всем подписчикам, не блокируясь на медленных.
+func (h *hub) publishRaw(data []byte) error {
+	msg, err := websocket.NewPreparedMessage(websocket.TextMessage, data)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.last = msg
+	for client := range h.clients {
+		client.push(msg)
+	}
+	return nil
+}
+
+// push кладёт сообщение в очередь клиента, вытесняя самое старое, если очередь заполнена.
+// Вызывается только под h.mu, поэтому других отправителей в очередь нет.
+func (c *hubClient) push(msg *websocket.PreparedMessage) {
+	select {
+	case c.queue <- msg:
+		return
+	default:
+	}
+
+	select {
+	case <-c.queue:
+		c.dropped.Add(1)
+	default:
+	}
+	select {
+	case c.queue <- msg:
+	default:
+	}
+}
+
+// streamHub устанавливает WebSocket-соединение и пересылает клиенту сообщения хаба,
+// пока клиент не отключится или запись не завершится ошибкой.
+//
+// Параметры:
+//   - w: HTTP ResponseWriter для обновления соединения до WebSocket
+//   - r: HTTP Request с информацией о клиенте
+//   - h: хаб, на который подписывается клиент
+//   - name: название потока для журнала
+func streamHub(w http.ResponseWriter, r *http.Request, h *hub, name string) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Ошибка обновления соединения до WebSocket (%s): %v", name, err)
+		return
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		return nil
+	})
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	client := h.subscribe()
+	defer func() {
+		h.unsubscribe(client)
+		if dropped := client.dropped.Load(); dropped > 0 {
+			log.Printf("Клиент %s (%s) не успевал принимать данные, пропущено сообщений: %d", r.RemoteAddr, name, dropped)
+		}
+	}()
+
+	pingTicker := time.NewTicker(30 * time.Second)
+	defer pingTicker.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case msg := <-client.queue:
+			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := conn.WritePreparedMessage(msg); err != nil {
+				return
+			}
+		case <-pingTicker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// publishMonitoringStatus рассылает клиентам хабов состояние мониторинга: при выключении -
+// "Мониторинг выключен", при включении - "Данные собираются..." до первого обновления кэша.
+// Вызывается под monitoringMutex, вместе со сменой состояния (см. publishIfMonitoring).
+func publishMonitoringStatus(enabled bool) {
+	if !enabled {
+		for _, h := range []*hub{cpuHub, memoryHub, processesHub} {
+			h.publishRaw([]byte(`{"monitoringEnabled":false,"message":"Мониторинг выключен"}`))
+		}
+		return
+	}
+
+	timestamp := time.Now().Format("2006-01-02 15:04:05")
+	cpuHub.publishRaw([]byte(`{"monitoringEnabled":true,"message":"Данные собираются...","cpu":0,"timestamp":"` + timestamp + `"}`))
+	memoryHub.publishRaw([]byte(`{"monitoringEnabled":true,"message":"Данные собираются...","memoryUsage":0,"usedMB":0,"totalMemory":0,"timestamp":"` + timestamp + `"}`))
+	processesHub.publishRaw([]byte(`{"monitoringEnabled":true,"message":"Данные собираются...","timestamp":"` + timestamp + `"}`))
+}
@@ -0,0 +1,271 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/gorilla/websocket"
+)
+
+// testMessages возвращает n разных подготовленных сообщений.
+func testMessages(t *testing.T, n int) []*websocket.PreparedMessage {
+	t.Helper()
+	msgs := make([]*websocket.PreparedMessage, n)
+	for i := range msgs {
+		msg, err := websocket.NewPreparedMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"n":%d}`, i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		msgs[i] = msg
+	}
+	return msgs
+}
+
+// drain забирает из очереди клиента всё, что в ней лежит, не дожидаясь новых сообщений.
+func drain(client *hubClient) []*websocket.PreparedMessage {
+	var msgs []*websocket.PreparedMessage
+	for {
+		select {
+		case msg := <-client.queue:
+			msgs = append(msgs, msg)
+		default:
+			return msgs
+		}
+	}
+}
+
+func TestHubClientPush(t *testing.T) {
+	tests := []struct {
+		name        string
+		pushes      int
+		wantFirst   int // Номер первого сообщения, оставшегося в очереди
+		wantDropped uint64
+	}{
+		{name: "one message", pushes: 1, wantFirst: 0, wantDropped: 0},
+		{name: "queue full", pushes: hubQueueSize, wantFirst: 0, wantDropped: 0},
+		{name: "oldest evicted", pushes: hubQueueSize + 1, wantFirst: 1, wantDropped: 1},
+		{name: "many evicted", pushes: 3 * hubQueueSize, wantFirst: 2 * hubQueueSize, wantDropped: 2 * hubQueueSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msgs := testMessages(t, tt.pushes)
+			client := &hubClient{queue: make(chan *websocket.PreparedMessage, hubQueueSize)}
+			for _, msg := range msgs {
+				client.push(msg)
+			}
+
+			got := drain(client)
+			want := msgs[tt.wantFirst:]
+			if len(got) != len(want) {
+				t.Fatalf("в очереди %d сообщений, ожидалось %d", len(got), len(want))
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Errorf("сообщение %d в очереди: ожидалось сообщение %d", i, tt.wantFirst+i)
+				}
+			}
+			if dropped := client.dropped.Load(); dropped != tt.wantDropped {
+				t.Errorf("dropped = %d, ожидалось %d", dropped, tt.wantDropped)
+			}
+		})
+	}
+}
+
+func TestHubSubscribe(t *testing.T) {
+	tests := []struct {
+		name      string
+		published []string
+		want      string
+	}{
+		{name: "nothing published", published: nil, want: `{"initial":true}`},
+		{name: "one update", published: []string{`{"n":1}`}, want: `{"n":1}`},
+		{name: "last of several", published: []string{`{"n":1}`, `{"n":2}`, `{"n":3}`}, want: `{"n":3}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHub(`{"initial":true}`)
+			sent := map[string]*websocket.PreparedMessage{`{"initial":true}`: h.last}
+			for _, data := range tt.published {
+				if err := h.publishRaw([]byte(data)); err != nil {
+					t.Fatal(err)
+				}
+				sent[data] = h.last
+			}
+
+			client := h.subscribe()
+			got := drain(client)
+			if len(got) != 1 {
+				t.Fatalf("новый подписчик получил %d сообщений, ожидалось 1", len(got))
+			}
+			if got[0] != sent[tt.want] {
+				t.Errorf("новый подписчик получил не последнее сообщение %s", tt.want)
+			}
+		})
+	}
+}
+
+func TestHubPublishDoesNotBlock(t *testing.T) {
+	tests := []struct {
+		name      string
+		clients   int
+		publishes int
+	}{
+		{name: "queue not full", clients: 3, publishes: hubQueueSize - 1},
+		{name: "queue overflows", clients: 3, publishes: 10 * hubQueueSize},
+		{name: "many clients", clients: 100, publishes: 10 * hubQueueSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHub(`{}`)
+			clients := make([]*hubClient, tt.clients)
+			for i := range clients {
+				clients[i] = h.subscribe() // Ни один клиент не читает свою очередь
+			}
+
+			done := make(chan error, 1)
+			go func() {
+				for i := range tt.publishes {
+					if err := h.publish(map[string]int{"n": i}); err != nil {
+						done <- err
+						return
+					}
+				}
+				done <- nil
+			}()
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatal(err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("publish заблокировался на заполненной очереди")
+			}
+
+			// В очереди каждого клиента сначала лежит сообщение, полученное при подписке.
+			wantDropped := uint64(max(0, 1+tt.publishes-hubQueueSize))
+			for i, client := range clients {
+				if dropped := client.dropped.Load(); dropped != wantDropped {
+					t.Errorf("клиент %d: dropped = %d, ожидалось %d", i, dropped, wantDropped)
+				}
+				if got := drain(client); got[len(got)-1] != h.last {
+					t.Errorf("клиент %d: последнее сообщение в очереди не совпадает с последним опубликованным", i)
+				}
+			}
+		})
+	}
+}
+
+// Бенчмарки сравнивают рассылку одного обновления N клиентам: прежний подход, где каждый клиент
+// сериализует кэш сам, и hub, где сообщение сериализуется один раз и раскладывается по очередям.
+// Метрика ns/client - предельная стоимость одного клиента: (T(N) - T(1)) / (N - 1), где T - время
+// рассылки одного обновления. Постоянная часть (у hub - сериализация) в неё не входит.
+// Запуск: go test -run=^$ -bench=. -benchmem ./server/ws
+
+// benchmarkClients - число подключённых клиентов в бенчмарках. Прогон с одним клиентом - база для ns/client.
+var benchmarkClients = []int{1, 10, 100, 1000}
+
+// benchmarkPerClient запускает run для каждого числа клиентов и сообщает ns/client относительно
+// прогона с одним клиентом. Если он исключён фильтром -bench, ns/client не сообщается.
+func benchmarkPerClient(b *testing.B, run func(b *testing.B, clients int)) {
+	var base float64
+	for _, clients := range benchmarkClients {
+		b.Run(fmt.Sprintf("clients=%d", clients), func(b *testing.B) {
+			run(b, clients)
+			perOp := float64(b.Elapsed().Nanoseconds()) / float64(b.N)
+			if clients == 1 {
+				base = perOp
+			} else if base > 0 {
+				b.ReportMetric((perOp-base)/float64(clients-1), "ns/client")
+			}
+		})
+	}
+}
+
+// benchmarkProcesses возвращает список процессов размером с типичный хост.
+func benchmarkProcesses() []models.ProcessInfo {
+	procs := make([]models.ProcessInfo, 300)
+	for i := range procs {
+		procs[i] = models.ProcessInfo{
+			PID:           int32(1000 + i),
+			Name:          fmt.Sprintf("process-%d", i),
+			Exe:           fmt.Sprintf("/usr/bin/process-%d", i),
+			Cmdline:       fmt.Sprintf("/usr/bin/process-%d --config /etc/process-%d.conf", i, i),
+			Username:      "www-data",
+			Status:        "sleep",
+			CreateTime:    1705300000000 + int64(i),
+			ParentPID:     1,
+			CPUPercent:    float64(i%100) / 3,
+			MemoryPercent: float64(i%50) / 7,
+			MemoryRSS:     uint64(i) * 1024 * 1024,
+			MemoryVMS:     uint64(i) * 4 * 1024 * 1024,
+			Ports:         []uint32{uint32(8000 + i)},
+		}
+	}
+	return procs
+}
+
+// BenchmarkPerClientMarshal - прежний подход: каждый клиент сериализует один и тот же список сам.
+func BenchmarkPerClientMarshal(b *testing.B) {
+	procs := benchmarkProcesses()
+	benchmarkPerClient(b, func(b *testing.B, clients int) {
+		for b.Loop() {
+			for range clients {
+				if _, err := json.Marshal(procs); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
+
+// BenchmarkHubPublish - hub: одна сериализация на обновление, клиенты забирают сообщения из своих очередей.
+func BenchmarkHubPublish(b *testing.B) {
+	procs := benchmarkProcesses()
+	benchmarkPerClient(b, func(b *testing.B, clients int) {
+		h := newHub(`{}`)
+		stop := make(chan struct{})
+		defer close(stop)
+		for range clients {
+			client := h.subscribe()
+			go func() {
+				for {
+					select {
+					case <-client.queue:
+					case <-stop:
+						return
+					}
+				}
+			}()
+		}
+
+		for b.Loop() {
+			if err := h.publish(procs); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkHubPublishSlowClients - ни один клиент не читает очередь: публикация не блокируется,
+// а старые сообщения вытесняются новыми.
+func BenchmarkHubPublishSlowClients(b *testing.B) {
+	procs := benchmarkProcesses()
+	benchmarkPerClient(b, func(b *testing.B, clients int) {
+		h := newHub(`{}`)
+		for range clients {
+			h.subscribe()
+		}
+
+		for b.Loop() {
+			if err := h.publish(procs); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,15 @@
+package ws
+
+import "github.com/RZhurakovskiy/agent/server/services"
+
+// integrityChecker проставляет процессам хэши и вердикты проверки целостности (может отсутствовать).
+var integrityChecker *services.IntegrityChecker
+
+// SetIntegrityChecker подключает проверку целостности исполняемых файлов к циклу обновления процессов.
+// Вызывается один раз при старте сервера, до включения мониторинга.
+//
+// Параметры:
+//   - checker: проверка целостности или nil, чтобы не проверять исполняемые файлы
+func SetIntegrityChecker(checker *services.IntegrityChecker) {
+	integrityChecker = checker
+}
@@ -0,0 +1,389 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/gorilla/websocket"
+)
+
+// Типы сообщений клиента /ws.
+const (
+	muxSubscribe   = "subscribe"
+	muxUnsubscribe = "unsubscribe"
+)
+
+// Служебные темы ответов /ws: подтверждения подписки и ошибки.
+const (
+	muxTopicSubscribed   = "subscribed"
+	muxTopicUnsubscribed = "unsubscribed"
+	muxTopicError        = "error"
+)
+
+const (
+	// muxMinInterval и muxMaxInterval ограничивают интервал, запрошенный клиентом.
+	muxMinInterval = time.Second
+	muxMaxInterval = 5 * time.Minute
+
+	// muxReadLimit - максимальный размер сообщения клиента.
+	muxReadLimit = 64 * 1024
+)
+
+// muxRequest - сообщение клиента /ws: подписка на тему или отписка от неё.
+type muxRequest struct {
+	Type     string            `json:"type"`               // subscribe или unsubscribe
+	Topic    string            `json:"topic"`              // Название темы
+	Interval int64             `json:"interval,omitempty"` // Интервал отправки в мс; 0 - интервал темы по умолчанию
+	Params   map[string]string `json:"params,omitempty"`   // Параметры темы (те же, что у отдельного эндпоинта)
+}
+
+// muxEnvelope - сообщение сервера /ws. Data совпадает с сообщением отдельного эндпоинта темы.
+type muxEnvelope struct {
+	Topic string `json:"topic"` // Тема данных или служебная тема (subscribed, unsubscribed, error)
+	TS    string `json:"ts"`    // Время отправки в формате "2006-01-02 15:04:05"
+	Data  any    `json:"data"`
+}
+
+// muxSubscription - подтверждение подписки или отписки.
+type muxSubscription struct {
+	Topic    string            `json:"topic"`
+	Interval int64             `json:"interval,omitempty"` // мс; нет у тем, отправляющих события по мере появления
+	Params   map[string]string `json:"params,omitempty"`
+}
+
+// muxStatus отправляется вместо данных темы, пока мониторинг выключен или данные ещё собираются.
+type muxStatus struct {
+	MonitoringEnabled bool   `json:"monitoringEnabled"`
+	Message           string `json:"message"`
+}
+
+// muxError - описание ошибки в ответ на сообщение клиента.
+type muxError struct {
+	Topic   string `json:"topic,omitempty"`
+	Message string `json:"message"`
+}
+
+// muxRunner отправляет данные одной подписки через send, пока не отменён ctx.
+type muxRunner func(ctx context.Context, interval time.Duration, send func(data any) error)
+
+// muxTopic описывает тему /ws.
+type muxTopic struct {
+	// interval - интервал по умолчанию (период обновления кэша темы); 0 - тема отправляет события
+	// по мере появления и интервал не принимает.
+	interval time.Duration
+	// open разбирает параметры подписки и возвращает функцию отправки данных.
+	open func(params url.Values) (muxRunner, error)
+}
+
+// muxTopics - темы /ws. Новый источник данных добавляется сюда, без отдельного обработчика и соединения.
+var muxTopics = map[string]muxTopic{
+	"cpu": {interval: time.Second, open: cachedTopic(func() (any, bool) {
+		cacheMutex.RLock()
+		defer cacheMutex.RUnlock()
+		return cpuCache, cpuCache.Timestamp != ""
+	})},
+	"memory": {interval: 3 * time.Second, open: cachedTopic(func() (any, bool) {
+		cacheMutex.RLock()
+		defer cacheMutex.RUnlock()
+		return memCache, memCache.Timestamp != ""
+	})},
+	"processes": {interval: 5 * time.Second, open: func(params url.Values) (muxRunner, error) {
+		view, err := parseProcessView(params)
+		if err != nil {
+			return nil, err
+		}
+		return pollTopic(func() (any, bool) {
+			cacheMutex.RLock()
+			procs := procsCache
+			timestamp := procsTimestamp
+			cacheMutex.RUnlock()
+			if procs == nil {
+				return nil, false
+			}
+			return view.apply(procs, timestamp), true
+		}), nil
+	}},
+	"process-events": {open: func(url.Values) (muxRunner, error) {
+		return runProcessEventsTopic, nil
+	}},
+	"disk": {interval: 5 * time.Second, open: cachedTopic(func() (any, bool) {
+		cacheMutex.RLock()
+		defer cacheMutex.RUnlock()
+		return diskCache, diskCache.Timestamp != ""
+	})},
+	"network": {interval: time.Second, open: cachedTopic(func() (any, bool) {
+		cacheMutex.RLock()
+		defer cacheMutex.RUnlock()
+		return networkCache, networkCache.Timestamp != ""
+	})},
+	"network-stack": {interval: 5 * time.Second, open: cachedTopic(func() (any, bool) {
+		cacheMutex.RLock()
+		defer cacheMutex.RUnlock()
+		return tcpStackCache, tcpStackCache.Timestamp != ""
+	})},
+	"connections": {interval: 5 * time.Second, open: func(params url.Values) (muxRunner, error) {
+		view, err := parseConnectionsView(params)
+		if err != nil {
+			return nil, err
+		}
+		return pollTopic(func() (any, bool) {
+			cacheMutex.RLock()
+			conns := connectionsCache
+			timestamp := connectionsTimestamp
+			cacheMutex.RUnlock()
+			if timestamp == "" {
+				return nil, false
+			}
+			return view.apply(conns, timestamp), true
+		}), nil
+	}},
+}
+
+// cachedTopic - тема без параметров, отправляющая значение из кэша.
+func cachedTopic(snapshot func() (any, bool)) func(url.Values) (muxRunner, error) {
+	return func(url.Values) (muxRunner, error) {
+		return pollTopic(snapshot), nil
+	}
+}
+
+// pollTopic отправляет снимок сразу после подписки и затем с заданным интервалом.
+// Пока мониторинг выключен или данные не собраны, вместо снимка отправляется статус.
+func pollTopic(snapshot func() (any, bool)) muxRunner {
+	return func(ctx context.Context, interval time.Duration, send func(data any) error) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			var data any
+			if !GetMonitoringEnabled() {
+				data = muxStatus{MonitoringEnabled: false, Message: "Мониторинг выключен"}
+			} else if snapshotData, ok := snapshot(); ok {
+				data = snapshotData
+			} else {
+				data = muxStatus{MonitoringEnabled: true, Message: "Данные собираются..."}
+			}
+			if err := send(data); err != nil {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}
+}
+
+// runProcessEventsTopic отправляет пачки событий запуска и завершения процессов по мере их обнаружения.
+func runProcessEventsTopic(ctx context.Context, _ time.Duration, send func(data any) error) {
+	events := make(chan []models.ProcessEvent, 16)
+	eventSubscribersMutex.Lock()
+	eventSubscribers[events] = struct{}{}
+	eventSubscribersMutex.Unlock()
+
+	defer func() {
+		eventSubscribersMutex.Lock()
+		delete(eventSubscribers, events)
+		eventSubscribersMutex.Unlock()
+	}()
+
+	if !GetMonitoringEnabled() {
+		if err := send(muxStatus{MonitoringEnabled: false, Message: "Мониторинг выключен"}); err != nil {
+			return
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case batch := <-events:
+			if err := send(batch); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// muxConn - одно соединение /ws с его подписками.
+type muxConn struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+	subs    map[string]context.CancelFunc
+}
+
+// StreamMux устанавливает WebSocket-соединение, по которому клиент подписывается на несколько тем сразу.
+// Клиент отправляет {"type":"subscribe","topic":"cpu","interval":1000,"params":{...}} и
+// {"type":"unsubscribe","topic":"cpu"}; сервер отвечает конвертами {"topic","ts","data"}.
+// Повторная подписка на тему заменяет прежнюю (с новыми интервалом и параметрами).
+//
+// Параметры:
+//   - w: HTTP ResponseWriter для обновления соединения до WebSocket
+//   - r: HTTP Request с информацией о клиенте
+func StreamMux(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Ошибка обновления соединения до WebSocket (мультиплексор): %v", err)
+		return
+	}
+	defer conn.Close()
+
+	c := &muxConn{conn: conn, subs: make(map[string]context.CancelFunc)}
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	conn.SetReadLimit(muxReadLimit)
+	conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		return nil
+	})
+
+	go c.keepAlive(ctx)
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+
+		var req muxRequest
+		if err := json.Unmarshal(message, &req); err != nil {
+			c.send(muxTopicError, muxError{Message: "Некорректный JSON. Ожидается: {\"type\": \"subscribe\"|\"unsubscribe\", \"topic\": \"...\"}"})
+			continue
+		}
+
+		switch req.Type {
+		case muxSubscribe:
+			c.subscribe(ctx, req)
+		case muxUnsubscribe:
+			c.unsubscribe(req.Topic)
+		default:
+			c.send(muxTopicError, muxError{Topic: req.Topic, Message: "Поле 'type' должно быть \"subscribe\" или \"unsubscribe\""})
+		}
+	}
+}
+
+// subscribe проверяет запрос подписки и запускает отправку данных темы.
+func (c *muxConn) subscribe(ctx context.Context, req muxRequest) {
+	topic, ok := muxTopics[req.Topic]
+	if !ok {
+		c.send(muxTopicError, muxError{Topic: req.Topic, Message: fmt.Sprintf("неизвестная тема %q, доступно: %s", req.Topic, muxTopicNames())})
+		return
+	}
+
+	interval := topic.interval
+	if req.Interval != 0 && topic.interval != 0 {
+		interval = time.Duration(req.Interval) * time.Millisecond
+		if interval < muxMinInterval || interval > muxMaxInterval {
+			c.send(muxTopicError, muxError{Topic: req.Topic, Message: fmt.Sprintf("интервал должен быть от %d до %d мс",
+				muxMinInterval.Milliseconds(), muxMaxInterval.Milliseconds())})
+			return
+		}
+	}
+
+	params := url.Values{}
+	for name, value := range req.Params {
+		params.Set(name, value)
+	}
+	run, err := topic.open(params)
+	if err != nil {
+		c.send(muxTopicError, muxError{Topic: req.Topic, Message: err.Error()})
+		return
+	}
+
+	if cancel, ok := c.subs[req.Topic]; ok {
+		cancel()
+	}
+	subCtx, cancel := context.WithCancel(ctx)
+	c.subs[req.Topic] = cancel
+
+	c.send(muxTopicSubscribed, muxSubscription{Topic: req.Topic, Interval: interval.Milliseconds(), Params: req.Params})
+	go run(subCtx, interval, func(data any) error {
+		return c.write(subCtx, req.Topic, data)
+	})
+}
+
+// unsubscribe останавливает отправку данных темы.
+func (c *muxConn) unsubscribe(topic string) {
+	cancel, ok := c.subs[topic]
+	if !ok {
+		c.send(muxTopicError, muxError{Topic: topic, Message: "подписки на тему нет"})
+		return
+	}
+	cancel()
+	delete(c.subs, topic)
+	c.send(muxTopicUnsubscribed, muxSubscription{Topic: topic})
+}
+
+// send отправляет клиенту конверт темы. Запись в соединение сериализована: gorilla/websocket
+// не допускает параллельных писателей. При ошибке записи соединение закрывается, и цикл чтения завершается.
+func (c *muxConn) send(topic string, data any) error {
+	return c.write(context.Background(), topic, data)
+}
+
+// write отправляет конверт темы, если ctx подписки ещё не отменён. Отмена проверяется под writeMu:
+// unsubscribe отменяет подписку до отправки подтверждения, поэтому после "unsubscribed" данные
+// отменённой подписки клиенту уже не приходят.
+func (c *muxConn) write(ctx context.Context, topic string, data any) error {
+	b, err := json.Marshal(muxEnvelope{Topic: topic, TS: time.Now().Format("2006-01-02 15:04:05"), Data: data})
+	if err != nil {
+		log.Printf("Ошибка сериализации сообщения темы %s: %v", topic, err)
+		return err
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if err := c.conn.WriteMessage(websocket.TextMessage, b); err != nil {
+		c.conn.Close()
+		return err
+	}
+	return nil
+}
+
+// keepAlive отправляет ping каждые 30 секунд, чтобы соединение без подписок не закрылось по таймауту чтения.
+func (c *muxConn) keepAlive(ctx context.Context) {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.writeMu.Lock()
+			err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second))
+			c.writeMu.Unlock()
+			if err != nil {
+				c.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// muxTopicNames возвращает названия тем через запятую для сообщений об ошибках.
+func muxTopicNames() string {
+	names := make([]string, 0, len(muxTopics))
+	for name := range muxTopics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
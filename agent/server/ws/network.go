@@ -0,0 +1,121 @@
+package ws
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/getmetrics"
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/gorilla/websocket"
+)
+
+// networkCache - кэш трафика сетевых интерфейсов, защищён cacheMutex.
+var networkCache models.NetworkStats
+
+// updateNetworkMetrics обновляет кэш трафика сетевых интерфейсов (каждую секунду).
+func updateNetworkMetrics(collector *getmetrics.NetworkCollector) {
+	stats, err := collector.Collect()
+	if err != nil {
+		log.Printf("Ошибка обновления кэша сетевых интерфейсов: %v", err)
+		return
+	}
+	stats.Timestamp = time.Now().Format("2006-01-02 15:04:05")
+
+	cacheMutex.Lock()
+	networkCache = stats
+	cacheMutex.Unlock()
+}
+
+// NetworkSnapshot возвращает последний снимок трафика интерфейсов из кэша.
+//
+// Возвращает:
+//   - models.NetworkStats: снимок трафика интерфейсов
+//   - bool: false, если мониторинг выключен или данные ещё не собраны
+func NetworkSnapshot() (models.NetworkStats, bool) {
+	if !GetMonitoringEnabled() {
+		return models.NetworkStats{}, false
+	}
+
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+	return networkCache, networkCache.Timestamp != ""
+}
+
+// StreamNetwork устанавливает WebSocket-соединение и начинает потоковую передачу
+// трафика сетевых интерфейсов клиенту. Данные отправляются каждую секунду из кэша.
+//
+// Параметры:
+//   - w: HTTP ResponseWriter для обновления соединения до WebSocket
+//   - r: HTTP Request с информацией о клиенте
+func StreamNetwork(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Ошибка обновления соединения до WebSocket (сеть): %v", err)
+		return
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		return nil
+	})
+
+	if err := writeNetwork(conn); err != nil {
+		log.Printf("Ошибка отправки первого сообщения сети: %v", err)
+		return
+	}
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := writeNetwork(conn); err != nil {
+			return
+		}
+	}
+}
+
+// writeNetwork отправляет кэшированный трафик сетевых интерфейсов через WebSocket-соединение.
+// Проверяет состояние мониторинга перед отправкой данных.
+//
+// Параметры:
+//   - conn: активное WebSocket-соединение
+//
+// Возвращает:
+//   - error: ошибка при сериализации или отправке данных
+func writeNetwork(conn *websocket.Conn) error {
+
+	monitoringMutex.RLock()
+	enabled := monitoringEnabled
+	monitoringMutex.RUnlock()
+
+	if !enabled {
+
+		statusMsg := `{"monitoringEnabled":false,"message":"Мониторинг выключен"}`
+		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		return conn.WriteMessage(websocket.TextMessage, []byte(statusMsg))
+	}
+
+	cacheMutex.RLock()
+	data := networkCache
+	cacheMutex.RUnlock()
+
+	if data.Timestamp == "" {
+		statusMsg := `{"monitoringEnabled":true,"message":"Данные собираются...","interfaces":[],"timestamp":"` + time.Now().Format("2006-01-02 15:04:05") + `"}`
+		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		return conn.WriteMessage(websocket.TextMessage, []byte(statusMsg))
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Ошибка сериализации метрик сети: %v", err)
+		return conn.WriteMessage(websocket.TextMessage, []byte(`{"error":"Ошибка сериализации данных"}`))
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	return conn.WriteMessage(websocket.TextMessage, b)
+}
@@ -0,0 +1,282 @@
+package ws
+
+import (
+	"encoding/json"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/getmetrics"
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/gorilla/websocket"
+)
+
+var (
+	// procsSeq - номер обновления procsCache (0 - данных ещё нет), защищён cacheMutex.
+	procsSeq uint64
+	// procsDelta - разница между двумя последними обновлениями procsCache, защищена cacheMutex.
+	procsDelta processDelta
+)
+
+// processDelta - разница двух последовательных снимков процессов до фильтрации по контейнеру.
+// Считается один раз на обновление кэша и используется всеми клиентами /ws/processes?encoding=delta.
+type processDelta struct {
+	seq       uint64
+	added     []models.ProcessInfo
+	removed   []models.ProcessInfo // Процессы из предыдущего снимка: ContainerID нужен для фильтра
+	changed   []processChange
+	timestamp string
+}
+
+// processChange - изменённые поля процесса и его контейнер для фильтра.
+type processChange struct {
+	change      models.ProcessChange
+	containerID string
+}
+
+// processDeltaRequest - сообщение клиента потока разниц: {"type":"resync"} запрашивает полный список.
+type processDeltaRequest struct {
+	Type string `json:"type"`
+}
+
+// newProcessDelta вычисляет разницу снимков prev и cur. Процесс определяется парой PID и времени запуска,
+// поэтому процесс с переиспользованным PID попадает и в removed, и в added.
+func newProcessDelta(prev, cur []models.ProcessInfo, now time.Time) processDelta {
+	delta := processDelta{timestamp: now.Format("2006-01-02 15:04:05")}
+
+	previous := make(map[processKey]models.ProcessInfo, len(prev))
+	for _, p := range prev {
+		previous[processKey{pid: p.PID, createTime: p.CreateTime}] = p
+	}
+
+	current := make(map[processKey]bool, len(cur))
+	for _, p := range cur {
+		key := processKey{pid: p.PID, createTime: p.CreateTime}
+		current[key] = true
+
+		old, ok := previous[key]
+		if !ok {
+			delta.added = append(delta.added, p)
+			continue
+		}
+		if fields := diffProcessFields(old, p); len(fields) > 0 {
+			delta.changed = append(delta.changed, processChange{
+				change:      models.ProcessChange{PID: p.PID, Fields: fields},
+				containerID: p.ContainerID,
+			})
+		}
+	}
+
+	for _, p := range prev {
+		if !current[processKey{pid: p.PID, createTime: p.CreateTime}] {
+			delta.removed = append(delta.removed, p)
+		}
+	}
+	return delta
+}
+
+// processKey однозначно идентифицирует процесс: PID переиспользуется, время создания - нет.
+type processKey struct {
+	pid        int32
+	createTime int64
+}
+
+// diffProcessFields возвращает поля b, отличающиеся от a, под именами из JSON-тегов ProcessInfo.
+// PID и CreateTime не сравниваются: по ним процессы сопоставляются.
+func diffProcessFields(a, b models.ProcessInfo) map[string]any {
+	fields := make(map[string]any)
+	set := func(name string, changed bool, value any) {
+		if changed {
+			fields[name] = value
+		}
+	}
+
+	set("name", a.Name != b.Name, b.Name)
+	set("exe", a.Exe != b.Exe, b.Exe)
+	set("cmdline", a.Cmdline != b.Cmdline, b.Cmdline)
+	set("username", a.Username != b.Username, b.Username)
+	set("status", a.Status != b.Status, b.Status)
+	set("parentPid", a.ParentPID != b.ParentPID, b.ParentPID)
+	set("cpuPercent", a.CPUPercent != b.CPUPercent, b.CPUPercent)
+	set("cpuHostPercent", a.CPUHostPercent != b.CPUHostPercent, b.CPUHostPercent)
+	set("memoryPercent", a.MemoryPercent != b.MemoryPercent, b.MemoryPercent)
+	set("memoryRss", a.MemoryRSS != b.MemoryRSS, b.MemoryRSS)
+	set("memoryVms", a.MemoryVMS != b.MemoryVMS, b.MemoryVMS)
+	set("ports", !slices.Equal(a.Ports, b.Ports), b.Ports)
+	set("cgroup", a.Cgroup != b.Cgroup, b.Cgroup)
+	set("containerId", a.ContainerID != b.ContainerID, b.ContainerID)
+	set("systemdUnit", a.SystemdUnit != b.SystemdUnit, b.SystemdUnit)
+	set("sha256", a.SHA256 != b.SHA256, b.SHA256)
+	set("verdict", a.Verdict != b.Verdict, b.Verdict)
+
+	return fields
+}
+
+// view возвращает разницу для клиента, выбравшего контейнер (пустая строка - все процессы).
+func (d processDelta) view(container string) models.ProcessDelta {
+	result := models.ProcessDelta{
+		Type:      models.ProcessStreamDelta,
+		Seq:       d.seq,
+		Added:     []models.ProcessInfo{},
+		Removed:   []int32{},
+		Changed:   []models.ProcessChange{},
+		Timestamp: d.timestamp,
+	}
+
+	for _, p := range d.added {
+		if container == "" || getmetrics.MatchContainer(p.ContainerID, container) {
+			result.Added = append(result.Added, p)
+		}
+	}
+	for _, p := range d.removed {
+		if container == "" || getmetrics.MatchContainer(p.ContainerID, container) {
+			result.Removed = append(result.Removed, p.PID)
+		}
+	}
+	for _, c := range d.changed {
+		if container == "" || getmetrics.MatchContainer(c.containerID, container) {
+			result.Changed = append(result.Changed, c.change)
+		}
+	}
+	return result
+}
+
+// processDeltaState - что уже отправлено клиенту потока разниц.
+type processDeltaState struct {
+	seq          uint64 // Номер последнего отправленного обновления; 0 - нужен полный список
+	statusSent   bool   // Статус "мониторинг выключен" или "данные собираются" уже отправлен
+	resyncNeeded bool   // Клиент запросил полный список
+}
+
+// streamProcessDeltas отправляет клиенту полный список процессов, а затем после каждого обновления кэша -
+// только добавленные, завершившиеся и изменившиеся процессы. Каждое сообщение несёт номер обновления seq:
+// если клиент видит пропуск, он отправляет {"type":"resync"} и получает полный список заново.
+// Клиенту, отставшему больше чем на одно обновление, полный список отправляется без запроса.
+//
+// Параметры:
+//   - conn: активное WebSocket-соединение
+//   - view: представление списка, выбранное клиентом при подключении
+func streamProcessDeltas(conn *websocket.Conn, view processView) {
+	conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		return nil
+	})
+
+	closed := make(chan struct{})
+	resync := make(chan struct{}, 1)
+	go func() {
+		defer close(closed)
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var req processDeltaRequest
+			if json.Unmarshal(message, &req) == nil && req.Type == "resync" {
+				select {
+				case resync <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	var state processDeltaState
+	if err := writeProcessUpdate(conn, view, &state); err != nil {
+		log.Printf("Ошибка отправки первого сообщения процессов: %v", err)
+		return
+	}
+
+	// Кэш обновляется раз в 5 секунд; проверка каждую секунду отправляет разницу без лишней задержки.
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	pingTicker := time.NewTicker(30 * time.Second)
+	defer pingTicker.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-resync:
+			state.resyncNeeded = true
+			if err := writeProcessUpdate(conn, view, &state); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := writeProcessUpdate(conn, view, &state); err != nil {
+				return
+			}
+		case <-pingTicker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeProcessUpdate отправляет клиенту потока разниц то, чего у него ещё нет: полный список,
+// разницу со следующим обновлением или статус мониторинга. Если новых данных нет, ничего не отправляет.
+//
+// Параметры:
+//   - conn: активное WebSocket-соединение
+//   - view: представление списка, выбранное клиентом при подключении
+//   - state: что уже отправлено клиенту
+//
+// Возвращает:
+//   - error: ошибка при сериализации или отправке данных
+func writeProcessUpdate(conn *websocket.Conn, view processView, state *processDeltaState) error {
+	if !GetMonitoringEnabled() {
+		// После включения мониторинга клиент получит полный список.
+		state.seq = 0
+		if state.statusSent {
+			return nil
+		}
+		state.statusSent = true
+		conn.SetWriteDeadline(time.Now().Add(30 * time.Second))
+		return conn.WriteMessage(websocket.TextMessage, []byte(`{"monitoringEnabled":false,"message":"Мониторинг выключен"}`))
+	}
+
+	cacheMutex.RLock()
+	procs := procsCache
+	seq := procsSeq
+	delta := procsDelta
+	cacheMutex.RUnlock()
+
+	if procs == nil {
+		if state.statusSent {
+			return nil
+		}
+		state.statusSent = true
+		statusMsg := `{"monitoringEnabled":true,"message":"Данные собираются...","timestamp":"` + time.Now().Format("2006-01-02 15:04:05") + `"}`
+		conn.SetWriteDeadline(time.Now().Add(30 * time.Second))
+		return conn.WriteMessage(websocket.TextMessage, []byte(statusMsg))
+	}
+	state.statusSent = false
+
+	var data any
+	switch {
+	case state.resyncNeeded || state.seq == 0 || seq > state.seq+1:
+		data = models.ProcessSnapshot{
+			Type:      models.ProcessStreamSnapshot,
+			Seq:       seq,
+			Processes: view.filter(procs),
+			Timestamp: delta.timestamp,
+		}
+	case seq == state.seq+1:
+		data = delta.view(view.container)
+	default:
+		return nil
+	}
+	state.seq = seq
+	state.resyncNeeded = false
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Ошибка сериализации списка процессов: %v", err)
+		return conn.WriteMessage(websocket.TextMessage, []byte(`{"error":"Ошибка сериализации данных"}`))
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(30 * time.Second))
+	return conn.WriteMessage(websocket.TextMessage, b)
+}
@@ -0,0 +1,47 @@
+package ws
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/models"
+)
+
+func TestNewProcessDeltaPorts(t *testing.T) {
+	base := models.ProcessInfo{PID: 1000, Name: "nginx", CreateTime: 1705300000000, CPUPercent: 1.5, Ports: []uint32{80, 443, 8080}}
+	withPorts := func(ports ...uint32) models.ProcessInfo {
+		p := base
+		p.Ports = ports
+		return p
+	}
+
+	tests := []struct {
+		name        string
+		prev, cur   models.ProcessInfo
+		wantChanged []string // Изменённые поля; nil - процесс не попадает в разницу
+	}{
+		{name: "unchanged multi-port process", prev: withPorts(80, 443, 8080), cur: withPorts(80, 443, 8080)},
+		{name: "unchanged without ports", prev: withPorts(), cur: withPorts()},
+		{name: "port added", prev: withPorts(80, 443), cur: withPorts(80, 443, 8080), wantChanged: []string{"ports"}},
+		{name: "port closed", prev: withPorts(80, 443, 8080), cur: withPorts(443, 8080), wantChanged: []string{"ports"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delta := newProcessDelta([]models.ProcessInfo{tt.prev}, []models.ProcessInfo{tt.cur}, time.Now())
+			if len(delta.added) != 0 || len(delta.removed) != 0 {
+				t.Fatalf("added=%d removed=%d, ожидался тот же процесс", len(delta.added), len(delta.removed))
+			}
+
+			var changed []string
+			if len(delta.changed) > 0 {
+				changed = slices.Sorted(maps.Keys(delta.changed[0].change.Fields))
+			}
+			if !slices.Equal(changed, tt.wantChanged) {
+				t.Errorf("изменённые поля %v, ожидалось %v", changed, tt.wantChanged)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package ws
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/RZhurakovskiy/agent/server/services"
+	"github.com/gorilla/websocket"
+)
+
+var (
+	// Трекер жизненного цикла процессов (может отсутствовать)
+	lifecycleTracker *services.LifecycleTracker
+	// Подписчики потока событий процессов: каждому соединению - свой буферизированный канал
+	eventSubscribers = make(map[chan []models.ProcessEvent]struct{})
+	// Мьютекс для безопасного доступа к подписчикам
+	eventSubscribersMutex sync.Mutex
+)
+
+// SetLifecycleTracker подключает трекер запуска и завершения процессов к циклу обновления процессов.
+// Вызывается один раз при старте сервера, до включения мониторинга.
+//
+// Параметры:
+//   - tracker: трекер жизненного цикла или nil, чтобы отключить события
+func SetLifecycleTracker(tracker *services.LifecycleTracker) {
+	lifecycleTracker = tracker
+}
+
+// observeLifecycle передаёт снимок процессов трекеру и рассылает найденные события подписчикам.
+func observeLifecycle(procs []models.ProcessInfo, now time.Time) {
+	if lifecycleTracker == nil {
+		return
+	}
+
+	events := lifecycleTracker.Observe(procs, now)
+	if len(events) == 0 {
+		return
+	}
+
+	eventSubscribersMutex.Lock()
+	defer eventSubscribersMutex.Unlock()
+
+	for ch := range eventSubscribers {
+		select {
+		case ch <- events:
+		default:
+			log.Printf("Клиент потока событий процессов не успевает читать, пачка из %d событий пропущена", len(events))
+		}
+	}
+}
+
+// StreamProcessEvents устанавливает WebSocket-соединение и отправляет клиенту события
+// запуска и завершения процессов по мере их обнаружения. Каждое сообщение - массив событий одного снимка.
+// Пока событий нет, соединение проверяется ping-сообщениями.
+//
+// Параметры:
+//   - w: HTTP ResponseWriter для обновления соединения до WebSocket
+//   - r: HTTP Request с информацией о клиенте
+func StreamProcessEvents(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Ошибка обновления соединения до WebSocket (события процессов): %v", err)
+		return
+	}
+	defer conn.Close()
+
+	events := make(chan []models.ProcessEvent, 16)
+	eventSubscribersMutex.Lock()
+	eventSubscribers[events] = struct{}{}
+	eventSubscribersMutex.Unlock()
+
+	defer func() {
+		eventSubscribersMutex.Lock()
+		delete(eventSubscribers, events)
+		eventSubscribersMutex.Unlock()
+	}()
+
+	if !GetMonitoringEnabled() {
+		statusMsg := `{"monitoringEnabled":false,"message":"Мониторинг выключен"}`
+		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(statusMsg)); err != nil {
+			log.Printf("Ошибка отправки статуса мониторинга (события процессов): %v", err)
+			return
+		}
+	}
+
+	pingTicker := time.NewTicker(30 * time.Second)
+	defer pingTicker.Stop()
+
+	for {
+		select {
+		case batch := <-events:
+			b, err := json.Marshal(batch)
+			if err != nil {
+				log.Printf("Ошибка сериализации событий процессов: %v", err)
+				continue
+			}
+			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := conn.WriteMessage(websocket.TextMessage, b); err != nil {
+				return
+			}
+		case <-pingTicker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package ws
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/getmetrics"
+	"github.com/gorilla/websocket"
+)
+
+// processQueryRequest - сообщение клиента /ws/processes: {"type":"query","params":{"name":"nginx","limit":"10"}}.
+// params - те же параметры выборки, что у /api/processes; новая выборка заменяет прежнюю целиком.
+type processQueryRequest struct {
+	Type   string            `json:"type"`
+	Params map[string]string `json:"params"`
+}
+
+// processQueryUpdate - результат разбора сообщения клиента: новое представление или ошибка.
+type processQueryUpdate struct {
+	view processView
+	err  error
+}
+
+// withQuery возвращает представление с новой выборкой из параметров params; mode и container не меняются.
+func (view processView) withQuery(params map[string]string) (processView, error) {
+	query := url.Values{}
+	for key, value := range params {
+		query.Set(key, value)
+	}
+	processQuery, err := getmetrics.ParseProcessQuery(query)
+	if err != nil {
+		return view, err
+	}
+	if view.tree && !processQuery.IsZero() {
+		return view, errTreeQuery
+	}
+	view.query = processQuery
+	return view, nil
+}
+
+// readProcessQueries читает сообщения клиента /ws/processes и передаёт в updates представления
+// с новой выборкой. Сообщения других типов пропускаются. При закрытии соединения закрывает closed.
+//
+// Параметры:
+//   - conn: активное WebSocket-соединение
+//   - view: представление списка, выбранное клиентом при подключении
+//   - updates: канал новых представлений и ошибок разбора
+//   - closed: закрывается, когда чтение из соединения завершилось
+//   - done: закрывается отправителем, когда обновления больше не принимаются
+func readProcessQueries(conn *websocket.Conn, view processView, updates chan<- processQueryUpdate, closed chan<- struct{}, done <-chan struct{}) {
+	defer close(closed)
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var req processQueryRequest
+		if json.Unmarshal(message, &req) != nil || req.Type != "query" {
+			continue
+		}
+
+		update := processQueryUpdate{}
+		update.view, update.err = view.withQuery(req.Params)
+		if update.err == nil {
+			view = update.view
+		}
+		select {
+		case updates <- update:
+		case <-done:
+			return
+		}
+	}
+}
+
+// writeProcessQueryError сообщает клиенту, что выборка не принята; прежняя выборка остаётся в силе.
+func writeProcessQueryError(conn *websocket.Conn, queryErr error) error {
+	b, err := json.Marshal(map[string]string{"error": queryErr.Error()})
+	if err != nil {
+		return err
+	}
+	conn.SetWriteDeadline(time.Now().Add(30 * time.Second))
+	return conn.WriteMessage(websocket.TextMessage, b)
+}
@@ -0,0 +1,55 @@
+package ws
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/RZhurakovskiy/agent/server/getmetrics"
+	"github.com/RZhurakovskiy/agent/server/models"
+)
+
+// tcpStackCache - кэш счётчиков TCP-стека, защищён cacheMutex.
+var tcpStackCache models.TCPStackStats
+
+// updateTCPStackMetrics обновляет кэш счётчиков TCP-стека и сохраняет их в историю (каждые 5 секунд).
+// Вне Linux счётчиков нет, и кэш остаётся пустым без записи в лог.
+func updateTCPStackMetrics(collector *getmetrics.TCPStackCollector) {
+	stats, err := collector.Collect()
+	if err != nil {
+		if !errors.Is(err, getmetrics.ErrTCPStackUnavailable) {
+			log.Printf("Ошибка обновления кэша счётчиков TCP-стека: %v", err)
+		}
+		return
+	}
+	now := time.Now()
+	stats.Timestamp = now.Format("2006-01-02 15:04:05")
+
+	cacheMutex.Lock()
+	tcpStackCache = stats
+	cacheMutex.Unlock()
+
+	if historyRecorder != nil {
+		historyRecorder.Record(models.MetricTCPRetrans, stats.TCP.RetransSegsPerSec, now)
+		historyRecorder.Record(models.MetricTCPOutRsts, stats.TCP.OutRstsPerSec, now)
+		historyRecorder.Record(models.MetricTCPEstabResets, stats.TCP.EstabResetsPerSec, now)
+		historyRecorder.Record(models.MetricTCPListenOverflows, stats.TCP.ListenOverflowsPerSec, now)
+		historyRecorder.Record(models.MetricTCPListenDrops, stats.TCP.ListenDropsPerSec, now)
+		historyRecorder.Record(models.MetricUDPRcvbufErrors, stats.UDP.RcvbufErrorsPerSec, now)
+	}
+}
+
+// TCPStackSnapshot возвращает последний снимок счётчиков TCP-стека из кэша.
+//
+// Возвращает:
+//   - models.TCPStackStats: снимок счётчиков
+//   - bool: false, если мониторинг выключен или данные ещё не собраны
+func TCPStackSnapshot() (models.TCPStackStats, bool) {
+	if !GetMonitoringEnabled() {
+		return models.TCPStackStats{}, false
+	}
+
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+	return tcpStackCache, tcpStackCache.Timestamp != ""
+}
@@ -3,29 +3,49 @@ package ws
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/RZhurakovskiy/agent/server/getmetrics"
 	"github.com/RZhurakovskiy/agent/server/models"
+	"github.com/RZhurakovskiy/agent/server/services"
 	"github.com/gorilla/websocket"
 	"github.com/shirou/gopsutil/v4/net"
 )
 
 // cpuPayload представляет структуру данных для передачи метрик CPU через WebSocket.
 type cpuPayload struct {
-	CPU       float64 `json:"cpu"`       // Процент использования CPU (0-100)
-	Timestamp string  `json:"timestamp"` // Временная метка в формате "2006-01-02 15:04:05"
+	CPU       float64                `json:"cpu"`                // Процент использования CPU (0-100)
+	Cores     []float64              `json:"cores"`              // Загрузка каждого логического ядра (0-100)
+	Breakdown models.CPUBreakdown    `json:"breakdown"`          // Распределение времени CPU по категориям, %
+	Pressure  *models.SystemPressure `json:"pressure,omitempty"` // Load average, uptime, очередь задач и PSI
+	Timestamp string                 `json:"timestamp"`          // Временная метка в формате "2006-01-02 15:04:05"
 }
 
 // memoryPayload представляет структуру данных для передачи метрик памяти через WebSocket.
 type memoryPayload struct {
-	MemoryUsage float64 `json:"memoryUsage"` // Процент использования памяти (0-100)
-	UsedMB      uint64  `json:"usedMB"`      // Используемая память в мегабайтах
-	TotalMemory uint64  `json:"totalMemory"` // Общий объем памяти в мегабайтах
-	Timestamp   string  `json:"timestamp"`   // Временная метка в формате "2006-01-02 15:04:05"
+	MemoryUsage        float64 `json:"memoryUsage"`        // Процент использования памяти (0-100)
+	UsedMB             uint64  `json:"usedMB"`             // Используемая память в мегабайтах
+	TotalMemory        uint64  `json:"totalMemory"`        // Общий объем памяти в мегабайтах
+	AvailableMB        uint64  `json:"availableMB"`        // Память, доступная без ухода в swap, в мегабайтах
+	CachedMB           uint64  `json:"cachedMB"`           // Страничный кэш в мегабайтах
+	BuffersMB          uint64  `json:"buffersMB"`          // Буферы блочных устройств в мегабайтах
+	SharedMB           uint64  `json:"sharedMB"`           // Разделяемая память (tmpfs, shm) в мегабайтах
+	SlabMB             uint64  `json:"slabMB"`             // Кэши ядра (slab) в мегабайтах
+	DirtyMB            uint64  `json:"dirtyMB"`            // Изменённые страницы, ожидающие записи на диск, в мегабайтах
+	SwapTotalMB        uint64  `json:"swapTotalMB"`        // Объём swap в мегабайтах
+	SwapUsedMB         uint64  `json:"swapUsedMB"`         // Занятый swap в мегабайтах
+	SwapUsage          float64 `json:"swapUsage"`          // Процент использования swap (0-100)
+	SwapInBytesPerSec  float64 `json:"swapInBytesPerSec"`  // Скорость чтения из swap, байт/с
+	SwapOutBytesPerSec float64 `json:"swapOutBytesPerSec"` // Скорость записи в swap, байт/с
+	OOMKills           uint64  `json:"oomKills"`           // Число OOM kill с загрузки системы
+	OOMKillsDelta      uint64  `json:"oomKillsDelta"`      // Число OOM kill с предыдущего замера
+	Timestamp          string  `json:"timestamp"`          // Временная метка в формате "2006-01-02 15:04:05"
 }
 
 // upgrader используется для обновления HTTP-соединения до WebSocket.
@@ -47,15 +67,23 @@ var (
 	memCache memoryPayload
 	// Кэш списка процессов
 	procsCache []models.ProcessInfo
+	// Время снимка procsCache
+	procsTimestamp string
 	// Мьютекс для безопасного доступа к кэшу из разных горутин
 	cacheMutex sync.RWMutex
 	// Контекст для управления жизненным циклом горутин обновления кэша
 	cacheCtx    context.Context
 	cacheCancel context.CancelFunc
+	// Закрывается, когда горутина обновления кэша завершилась
+	cacheDone chan struct{}
 	// Состояние мониторинга (включен/выключен)
 	monitoringEnabled bool
 	// Мьютекс для безопасного доступа к состоянию мониторинга
 	monitoringMutex sync.RWMutex
+	// Детектор всплесков, получающий каждый снимок процессов (может отсутствовать)
+	spikeRecorder *services.SpikeRecorder
+	// Хранилище истории метрик хоста (может отсутствовать)
+	historyRecorder *services.HistoryRecorder
 )
 
 // init инициализирует состояние мониторинга.
@@ -67,12 +95,23 @@ func init() {
 }
 
 // updateCacheLoop запускает бесконечные циклы обновления кэша метрик.
-// CPU обновляется каждую секунду, память - каждые 3 секунды, процессы - каждые 5 секунд.
-// Циклы проверяют состояние мониторинга и обновляют кэш только если мониторинг включен.
+// CPU и сеть обновляются каждую секунду, память - каждые 3 секунды, процессы, диски и счётчики
+// TCP-стека - каждые 5 секунд. Циклы проверяют состояние мониторинга и обновляют кэш только если мониторинг включен.
+// Сборщики CPU, памяти, процессов, дисков, сети и TCP-стека создаются на каждый запуск цикла, чтобы первый замер
+// после паузы не усреднял скорости и CPU% процессов за всё время, пока мониторинг был выключен.
 //
 // Параметры:
 //   - ctx: контекст для управления жизненным циклом горутин
-func updateCacheLoop(ctx context.Context) {
+//   - done: закрывается при завершении цикла
+func updateCacheLoop(ctx context.Context, done chan<- struct{}) {
+	defer close(done)
+
+	cpuCollector := getmetrics.NewCPUCollector()
+	memCollector := getmetrics.NewMemoryCollector()
+	processCollector := getmetrics.NewProcessCollector()
+	diskCollector := getmetrics.NewDiskCollector()
+	networkCollector := getmetrics.NewNetworkCollector()
+	tcpStackCollector := getmetrics.NewTCPStackCollector()
 
 	cpuTicker := time.NewTicker(1 * time.Second)
 	defer cpuTicker.Stop()
@@ -83,6 +122,15 @@ func updateCacheLoop(ctx context.Context) {
 	procTicker := time.NewTicker(5 * time.Second)
 	defer procTicker.Stop()
 
+	diskTicker := time.NewTicker(5 * time.Second)
+	defer diskTicker.Stop()
+
+	networkTicker := time.NewTicker(1 * time.Second)
+	defer networkTicker.Stop()
+
+	tcpStackTicker := time.NewTicker(5 * time.Second)
+	defer tcpStackTicker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -92,265 +140,316 @@ func updateCacheLoop(ctx context.Context) {
 			enabled := monitoringEnabled
 			monitoringMutex.RUnlock()
 			if enabled {
-				updateCPUMetrics()
+				updateCPUMetrics(cpuCollector)
 			}
 		case <-memTicker.C:
 			monitoringMutex.RLock()
 			enabled := monitoringEnabled
 			monitoringMutex.RUnlock()
 			if enabled {
-				updateMemoryMetrics()
+				updateMemoryMetrics(memCollector)
 			}
 		case <-procTicker.C:
 			monitoringMutex.RLock()
 			enabled := monitoringEnabled
 			monitoringMutex.RUnlock()
 			if enabled {
-				updateProcessMetrics()
+				updateProcessMetrics(processCollector)
+			}
+		case <-diskTicker.C:
+			monitoringMutex.RLock()
+			enabled := monitoringEnabled
+			monitoringMutex.RUnlock()
+			if enabled {
+				updateDiskMetrics(diskCollector)
+			}
+		case <-networkTicker.C:
+			monitoringMutex.RLock()
+			enabled := monitoringEnabled
+			monitoringMutex.RUnlock()
+			if enabled {
+				updateNetworkMetrics(networkCollector)
+			}
+		case <-tcpStackTicker.C:
+			monitoringMutex.RLock()
+			enabled := monitoringEnabled
+			monitoringMutex.RUnlock()
+			if enabled {
+				updateTCPStackMetrics(tcpStackCollector)
 			}
 		}
 	}
 }
 
 // updateCPUMetrics обновляет кэш метрик CPU (каждую секунду).
-func updateCPUMetrics() {
-	if usage, err := getmetrics.UsageCPU(100 * time.Millisecond); err == nil {
-		cacheMutex.Lock()
-		cpuCache = cpuPayload{
-			CPU:       usage,
-			Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+// Загрузка считается по приросту счётчиков времени CPU с предыдущего вызова.
+// Вместе с CPU снимаются load average и PSI.
+func updateCPUMetrics(collector *getmetrics.CPUCollector) {
+	if stats, err := collector.Collect(); err == nil {
+		now := time.Now()
+
+		var pressure *models.SystemPressure
+		if p, err := getmetrics.SystemPressure(); err == nil {
+			pressure = &p
+		} else {
+			log.Printf("Ошибка получения нагрузки на систему: %v", err)
+		}
+
+		payload := cpuPayload{
+			CPU:       stats.Total,
+			Cores:     stats.Cores,
+			Breakdown: stats.Breakdown,
+			Pressure:  pressure,
+			Timestamp: now.Format("2006-01-02 15:04:05"),
 		}
+		cacheMutex.Lock()
+		cpuCache = payload
 		cacheMutex.Unlock()
+
+		if err := cpuHub.publishIfMonitoring(payload); err != nil {
+			log.Printf("Ошибка сериализации метрик CPU: %v", err)
+		}
+
+		if historyRecorder != nil {
+			historyRecorder.Record(models.MetricCPU, stats.Total, now)
+			if pressure != nil {
+				recordPressureHistory(*pressure, now)
+			}
+		}
 	} else {
 		log.Printf("Ошибка обновления кэша CPU: %v", err)
 	}
 }
 
+// recordPressureHistory сохраняет в историю load1 и значения PSI avg10.
+// Показатели, которых нет на текущей системе, не записываются.
+func recordPressureHistory(pressure models.SystemPressure, now time.Time) {
+	historyRecorder.Record(models.MetricLoad1, pressure.Load1, now)
+
+	if pressure.PSI == nil {
+		return
+	}
+	if cpu := pressure.PSI.CPU; cpu != nil {
+		historyRecorder.Record(models.MetricPSICPUSome, cpu.Some.Avg10, now)
+	}
+	if memory := pressure.PSI.Memory; memory != nil {
+		historyRecorder.Record(models.MetricPSIMemorySome, memory.Some.Avg10, now)
+		if memory.Full != nil {
+			historyRecorder.Record(models.MetricPSIMemoryFull, memory.Full.Avg10, now)
+		}
+	}
+	if io := pressure.PSI.IO; io != nil {
+		historyRecorder.Record(models.MetricPSIIOSome, io.Some.Avg10, now)
+		if io.Full != nil {
+			historyRecorder.Record(models.MetricPSIIOFull, io.Full.Avg10, now)
+		}
+	}
+}
+
 // updateMemoryMetrics обновляет кэш метрик памяти (каждые 3 секунды).
-func updateMemoryMetrics() {
-	if usage, total, used, err := getmetrics.UsageMemory(); err == nil {
-		cacheMutex.Lock()
-		memCache = memoryPayload{
-			MemoryUsage: usage,
-			UsedMB:      used,
-			TotalMemory: total,
-			Timestamp:   time.Now().Format("2006-01-02 15:04:05"),
+func updateMemoryMetrics(collector *getmetrics.MemoryCollector) {
+	if stats, err := collector.Collect(); err == nil {
+		const mib = uint64(1024 * 1024)
+		now := time.Now()
+		payload := memoryPayload{
+			MemoryUsage:        stats.UsedPercent,
+			UsedMB:             stats.Used / mib,
+			TotalMemory:        stats.Total / mib,
+			AvailableMB:        stats.Available / mib,
+			CachedMB:           stats.Cached / mib,
+			BuffersMB:          stats.Buffers / mib,
+			SharedMB:           stats.Shared / mib,
+			SlabMB:             stats.Slab / mib,
+			DirtyMB:            stats.Dirty / mib,
+			SwapTotalMB:        stats.SwapTotal / mib,
+			SwapUsedMB:         stats.SwapUsed / mib,
+			SwapUsage:          stats.SwapUsedPercent,
+			SwapInBytesPerSec:  stats.SwapInRate,
+			SwapOutBytesPerSec: stats.SwapOutRate,
+			OOMKills:           stats.OOMKills,
+			OOMKillsDelta:      stats.OOMKillsDelta,
+			Timestamp:          now.Format("2006-01-02 15:04:05"),
 		}
+		cacheMutex.Lock()
+		memCache = payload
 		cacheMutex.Unlock()
+
+		if err := memoryHub.publishIfMonitoring(payload); err != nil {
+			log.Printf("Ошибка сериализации метрик памяти: %v", err)
+		}
+
+		if historyRecorder != nil {
+			historyRecorder.Record(models.MetricMemory, stats.UsedPercent, now)
+		}
 	} else {
 		log.Printf("Ошибка обновления кэша памяти: %v", err)
 	}
 }
 
-// updateProcessMetrics обновляет кэш метрик процессов (каждые 5 секунд).
-func updateProcessMetrics() {
+// updateProcessMetrics обновляет кэш метрик процессов и соединений (каждые 5 секунд).
+// Перед записью в кэш процессам проставляются хэши и вердикты проверки целостности.
+func updateProcessMetrics(collector *getmetrics.ProcessCollector) {
 
-	allConnections, err := net.Connections("all")
-	if err != nil {
-		log.Printf("Ошибка получения сетевых соединений: %v", err)
+	allConnections, connErr := net.Connections("all")
+	if connErr != nil {
+		log.Printf("Ошибка получения сетевых соединений: %v", connErr)
 		allConnections = []net.ConnectionStat{}
 	}
 
-	if procs, err := getmetrics.UsageProcess(allConnections); err == nil {
+	if procs, err := collector.Collect(allConnections); err == nil {
+		now := time.Now()
+		integrityChecker.VerifyCached(procs, now)
+
+		// Разница считается под блокировкой вместе с заменой снимка: после выключения и включения
+		// мониторинга прежний цикл может ещё работать, и оба цикла обновляют procsCache.
 		cacheMutex.Lock()
+		delta := newProcessDelta(procsCache, procs, now)
 		procsCache = procs
+		procsTimestamp = delta.timestamp
+		procsSeq++
+		delta.seq = procsSeq
+		procsDelta = delta
 		cacheMutex.Unlock()
+
+		if err := processesHub.publishIfMonitoring(procs); err != nil {
+			log.Printf("Ошибка сериализации списка процессов: %v", err)
+		}
+
+		if connErr == nil {
+			updateConnections(allConnections, procs)
+		}
+
+		if spikeRecorder != nil {
+			spikeRecorder.Observe(procs, now)
+		}
+		observeLifecycle(procs, now)
 	} else {
 		log.Printf("Ошибка обновления кэша процессов: %v", err)
 	}
 }
 
 // StreamCPU устанавливает WebSocket-соединение и начинает потоковую передачу
-// метрик CPU клиенту. Данные отправляются каждую секунду из кэша.
+// метрик CPU клиенту. Данные отправляются после каждого обновления кэша (раз в секунду)
+// через cpuHub: сообщение сериализуется один раз для всех клиентов.
 //
 // Параметры:
 //   - w: HTTP ResponseWriter для обновления соединения до WebSocket
 //   - r: HTTP Request с информацией о клиенте
 func StreamCPU(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
-	if err != nil {
-		log.Printf("Ошибка обновления соединения до WebSocket (CPU): %v", err)
-		return
-	}
-	defer conn.Close()
-
-	conn.SetReadDeadline(time.Now().Add(60 * time.Second))
-	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-	conn.SetPongHandler(func(string) error {
-		conn.SetReadDeadline(time.Now().Add(60 * time.Second))
-		return nil
-	})
-
-	monitoringMutex.RLock()
-	enabled := monitoringEnabled
-	monitoringMutex.RUnlock()
-
-	if enabled {
-
-		if err := writeCPU(conn); err != nil {
-			log.Printf("Ошибка отправки первого сообщения CPU: %v", err)
-			return
-		}
-	} else {
-
-		statusMsg := `{"monitoringEnabled":false,"message":"Мониторинг выключен"}`
-		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-		if err := conn.WriteMessage(websocket.TextMessage, []byte(statusMsg)); err != nil {
-			log.Printf("Ошибка отправки статуса мониторинга (CPU): %v", err)
-			return
-		}
-	}
-
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		if err := writeCPU(conn); err != nil {
-			return
-		}
-	}
+	streamHub(w, r, cpuHub, "CPU")
 }
 
-// writeCPU отправляет кэшированные метрики CPU через WebSocket-соединение.
-// Проверяет состояние мониторинга перед отправкой данных.
+// StreamMemory устанавливает WebSocket-соединение и начинает потоковую передачу
+// метрик памяти клиенту. Данные отправляются после каждого обновления кэша (раз в 3 секунды)
+// через memoryHub: сообщение сериализуется один раз для всех клиентов.
 //
 // Параметры:
-//   - conn: активное WebSocket-соединение
-//
-// Возвращает:
-//   - error: ошибка при сериализации или отправке данных
-func writeCPU(conn *websocket.Conn) error {
+//   - w: HTTP ResponseWriter для обновления соединения до WebSocket
+//   - r: HTTP Request с информацией о клиенте
+func StreamMemory(w http.ResponseWriter, r *http.Request) {
+	streamHub(w, r, memoryHub, "память")
+}
 
-	monitoringMutex.RLock()
-	enabled := monitoringEnabled
-	monitoringMutex.RUnlock()
+// errTreeQuery - выборка запрошена вместе с деревом процессов.
+var errTreeQuery = errors.New("mode=tree не поддерживается вместе с фильтрами, сортировкой, limit и offset")
 
-	if !enabled {
+// processView - параметры представления списка процессов для одного клиента /ws/processes.
+type processView struct {
+	tree      bool                    // отправлять дерево процессов вместо плоского списка
+	container string                  // фильтр по контейнеру
+	query     getmetrics.ProcessQuery // фильтры, сортировка и окно (параметры /api/processes)
+}
 
-		statusMsg := `{"monitoringEnabled":false,"message":"Мониторинг выключен"}`
-		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-		return conn.WriteMessage(websocket.TextMessage, []byte(statusMsg))
+// parseProcessView разбирает параметры представления списка процессов: mode=tree, container
+// и выборку из параметров /api/processes. Дерево строится из всех процессов, поэтому выборка с ним не сочетается.
+func parseProcessView(query url.Values) (processView, error) {
+	processQuery, err := getmetrics.ParseProcessQuery(query)
+	if err != nil {
+		return processView{}, err
+	}
+	view := processView{
+		tree:      query.Get("mode") == "tree",
+		container: query.Get("container"),
+		query:     processQuery,
+	}
+	if view.tree && !processQuery.IsZero() {
+		return view, errTreeQuery
 	}
+	return view, nil
+}
 
-	cacheMutex.RLock()
-	data := cpuCache
-	cacheMutex.RUnlock()
+// shared сообщает, что клиенту нужен полный список без изменений - общий для всех таких клиентов.
+func (view processView) shared() bool {
+	return !view.tree && view.container == "" && view.query.IsZero()
+}
 
-	if data.Timestamp == "" {
-		statusMsg := `{"monitoringEnabled":true,"message":"Данные собираются...","cpu":0,"timestamp":"` + time.Now().Format("2006-01-02 15:04:05") + `"}`
-		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-		return conn.WriteMessage(websocket.TextMessage, []byte(statusMsg))
+// filter оставляет процессы выбранного контейнера.
+func (view processView) filter(procs []models.ProcessInfo) []models.ProcessInfo {
+	if view.container == "" {
+		return procs
 	}
-
-	b, err := json.Marshal(data)
-	if err != nil {
-		log.Printf("Ошибка сериализации метрик CPU: %v", err)
-		return conn.WriteMessage(websocket.TextMessage, []byte(`{"error":"Ошибка сериализации данных"}`))
+	filtered := make([]models.ProcessInfo, 0, len(procs))
+	for _, p := range procs {
+		if getmetrics.MatchContainer(p.ContainerID, view.container) {
+			filtered = append(filtered, p)
+		}
 	}
+	return filtered
+}
 
-	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-	return conn.WriteMessage(websocket.TextMessage, b)
+// apply оставляет процессы выбранного контейнера и строит из них дерево или применяет выборку.
+// Без выборки возвращается весь список, с выборкой - окно models.ProcessPage со временем снимка timestamp.
+func (view processView) apply(procs []models.ProcessInfo, timestamp string) any {
+	procs = view.filter(procs)
+	if view.tree {
+		return getmetrics.BuildProcessTree(procs)
+	}
+	if view.query.IsZero() {
+		return procs
+	}
+	page := getmetrics.QueryProcesses(procs, view.query)
+	page.Timestamp = timestamp
+	return page
 }
 
-// StreamMemory устанавливает WebSocket-соединение и начинает потоковую передачу
-// метрик памяти клиенту. Данные отправляются каждую секунду из кэша.
+// StreamProcesses устанавливает WebSocket-соединение и начинает потоковую передачу
+// списка процессов клиенту. Данные отправляются после каждого обновления кэша (раз в 5 секунд);
+// полный список без параметров сериализуется один раз для всех клиентов (processesHub).
+// С параметром ?mode=tree вместо плоского списка отправляется дерево процессов
+// с суммарными CPU и RSS поддеревьев. Параметр ?container=<id> оставляет только процессы
+// контейнера (ID или префикс ID, "none" - процессы вне контейнеров).
+// С параметром ?encoding=delta после полного списка отправляются только изменения (см. streamProcessDeltas).
+// Параметры выборки /api/processes (фильтры, sort, limit, offset) задаются в URL или сообщением
+// {"type":"query","params":{...}}; с выборкой вместо списка отправляется окно models.ProcessPage.
 //
 // Параметры:
 //   - w: HTTP ResponseWriter для обновления соединения до WebSocket
 //   - r: HTTP Request с информацией о клиенте
-func StreamMemory(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+func StreamProcesses(w http.ResponseWriter, r *http.Request) {
+	view, err := parseProcessView(r.URL.Query())
 	if err != nil {
-		log.Printf("Ошибка обновления соединения до WebSocket (память): %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	defer conn.Close()
-
-	conn.SetReadDeadline(time.Now().Add(60 * time.Second))
-	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-	conn.SetPongHandler(func(string) error {
-		conn.SetReadDeadline(time.Now().Add(60 * time.Second))
-		return nil
-	})
-
-	monitoringMutex.RLock()
-	enabled := monitoringEnabled
-	monitoringMutex.RUnlock()
-
-	if enabled {
-
-		if err := writeMemory(conn); err != nil {
-			log.Printf("Ошибка отправки первого сообщения памяти: %v", err)
-			return
-		}
-	} else {
 
-		statusMsg := `{"monitoringEnabled":false,"message":"Мониторинг выключен"}`
-		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-		if err := conn.WriteMessage(websocket.TextMessage, []byte(statusMsg)); err != nil {
-			log.Printf("Ошибка отправки статуса мониторинга (память): %v", err)
+	delta := false
+	switch encoding := r.URL.Query().Get("encoding"); encoding {
+	case "", "full":
+	case "delta":
+		if view.tree {
+			http.Error(w, "encoding=delta не поддерживается вместе с mode=tree", http.StatusBadRequest)
 			return
 		}
-	}
-
-	ticker := time.NewTicker(3 * time.Second)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		if err := writeMemory(conn); err != nil {
+		if !view.query.IsZero() {
+			http.Error(w, "encoding=delta не поддерживается вместе с фильтрами, сортировкой, limit и offset", http.StatusBadRequest)
 			return
 		}
-	}
-}
-
-// writeMemory отправляет кэшированные метрики памяти через WebSocket-соединение.
-// Проверяет состояние мониторинга перед отправкой данных.
-//
-// Параметры:
-//   - conn: активное WebSocket-соединение
-//
-// Возвращает:
-//   - error: ошибка при сериализации или отправке данных
-func writeMemory(conn *websocket.Conn) error {
-
-	monitoringMutex.RLock()
-	enabled := monitoringEnabled
-	monitoringMutex.RUnlock()
-
-	if !enabled {
-
-		statusMsg := `{"monitoringEnabled":false,"message":"Мониторинг выключен"}`
-		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-		return conn.WriteMessage(websocket.TextMessage, []byte(statusMsg))
-	}
-
-	cacheMutex.RLock()
-	data := memCache
-	cacheMutex.RUnlock()
-
-	if data.Timestamp == "" {
-		statusMsg := `{"monitoringEnabled":true,"message":"Данные собираются...","memoryUsage":0,"usedMB":0,"totalMemory":0,"timestamp":"` + time.Now().Format("2006-01-02 15:04:05") + `"}`
-		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-		return conn.WriteMessage(websocket.TextMessage, []byte(statusMsg))
-	}
-
-	b, err := json.Marshal(data)
-	if err != nil {
-		log.Printf("Ошибка сериализации метрик памяти: %v", err)
-		return conn.WriteMessage(websocket.TextMessage, []byte(`{"error":"Ошибка сериализации данных"}`))
+		delta = true
+	default:
+		http.Error(w, "некорректное значение параметра encoding: "+strconv.Quote(encoding)+", допустимо: full, delta", http.StatusBadRequest)
+		return
 	}
 
-	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-	return conn.WriteMessage(websocket.TextMessage, b)
-}
-
-// StreamProcesses устанавливает WebSocket-соединение и начинает потоковую передачу
-// списка процессов клиенту. Данные отправляются каждую секунду из кэша.
-//
-// Параметры:
-//   - w: HTTP ResponseWriter для обновления соединения до WebSocket
-//   - r: HTTP Request с информацией о клиенте
-func StreamProcesses(w http.ResponseWriter, r *http.Request) {
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("Ошибка обновления соединения до WebSocket (процессы): %v", err)
@@ -358,6 +457,11 @@ func StreamProcesses(w http.ResponseWriter, r *http.Request) {
 	}
 	defer conn.Close()
 
+	if delta {
+		streamProcessDeltas(conn, view)
+		return
+	}
+
 	conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 	conn.SetWriteDeadline(time.Now().Add(30 * time.Second))
 	conn.SetPongHandler(func(string) error {
@@ -365,32 +469,77 @@ func StreamProcesses(w http.ResponseWriter, r *http.Request) {
 		return nil
 	})
 
-	monitoringMutex.RLock()
-	enabled := monitoringEnabled
-	monitoringMutex.RUnlock()
-
-	if enabled {
-
-		if err := writeProcesses(conn); err != nil {
-			log.Printf("Ошибка отправки первого сообщения процессов: %v", err)
-			return
+	closed := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	updates := make(chan processQueryUpdate, 1)
+	go readProcessQueries(conn, view, updates, closed, done)
+
+	// Клиент без выборки, контейнера и дерева получает общий список из processesHub,
+	// остальным список отбирается из кэша по таймеру.
+	var client *hubClient
+	defer func() {
+		if client != nil {
+			processesHub.unsubscribe(client)
 		}
-	} else {
-
-		statusMsg := `{"monitoringEnabled":false,"message":"Мониторинг выключен"}`
-		conn.SetWriteDeadline(time.Now().Add(30 * time.Second))
-		if err := conn.WriteMessage(websocket.TextMessage, []byte(statusMsg)); err != nil {
-			log.Printf("Ошибка отправки статуса мониторинга (процессы): %v", err)
-			return
+	}()
+	setView := func(next processView) error {
+		view = next
+		if client != nil {
+			processesHub.unsubscribe(client)
+			client = nil
+		}
+		if view.shared() {
+			client = processesHub.subscribe()
+			return nil
 		}
+		return writeProcesses(conn, view)
+	}
+	if err := setView(view); err != nil {
+		log.Printf("Ошибка отправки первого сообщения процессов: %v", err)
+		return
 	}
 
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
+	pingTicker := time.NewTicker(30 * time.Second)
+	defer pingTicker.Stop()
+
+	for {
+		// Пока клиент не подписан на хаб, queue равен nil и не выбирается.
+		var queue chan *websocket.PreparedMessage
+		if client != nil {
+			queue = client.queue
+		}
 
-	for range ticker.C {
-		if err := writeProcesses(conn); err != nil {
+		select {
+		case <-closed:
 			return
+		case update := <-updates:
+			if update.err != nil {
+				if err := writeProcessQueryError(conn, update.err); err != nil {
+					return
+				}
+				continue
+			}
+			if err := setView(update.view); err != nil {
+				return
+			}
+		case msg := <-queue:
+			conn.SetWriteDeadline(time.Now().Add(30 * time.Second))
+			if err := conn.WritePreparedMessage(msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if client == nil {
+				if err := writeProcesses(conn, view); err != nil {
+					return
+				}
+			}
+		case <-pingTicker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				return
+			}
 		}
 	}
 }
@@ -400,10 +549,11 @@ func StreamProcesses(w http.ResponseWriter, r *http.Request) {
 //
 // Параметры:
 //   - conn: активное WebSocket-соединение
+//   - view: текущее представление списка клиента
 //
 // Возвращает:
 //   - error: ошибка при сериализации или отправке данных
-func writeProcesses(conn *websocket.Conn) error {
+func writeProcesses(conn *websocket.Conn, view processView) error {
 
 	monitoringMutex.RLock()
 	enabled := monitoringEnabled
@@ -417,10 +567,11 @@ func writeProcesses(conn *websocket.Conn) error {
 	}
 
 	cacheMutex.RLock()
-	data := procsCache
+	procs := procsCache
+	timestamp := procsTimestamp
 	cacheMutex.RUnlock()
 
-	b, err := json.Marshal(data)
+	b, err := json.Marshal(view.apply(procs, timestamp))
 	if err != nil {
 		log.Printf("Ошибка сериализации списка процессов: %v", err)
 		return conn.WriteMessage(websocket.TextMessage, []byte(`{"error":"Ошибка сериализации данных"}`))
@@ -430,6 +581,22 @@ func writeProcesses(conn *websocket.Conn) error {
 	return conn.WriteMessage(websocket.TextMessage, b)
 }
 
+// ProcessesSnapshot возвращает последний снимок процессов из кэша.
+//
+// Возвращает:
+//   - []models.ProcessInfo: список процессов
+//   - string: время снимка
+//   - bool: false, если мониторинг выключен или данные ещё не собраны
+func ProcessesSnapshot() ([]models.ProcessInfo, string, bool) {
+	if !GetMonitoringEnabled() {
+		return nil, "", false
+	}
+
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+	return procsCache, procsTimestamp, procsCache != nil
+}
+
 // SetMonitoringEnabled устанавливает состояние мониторинга (включен/выключен).
 // При включении запускает горутину обновления кэша, при выключении останавливает её.
 //
@@ -439,6 +606,9 @@ func SetMonitoringEnabled(enabled bool) {
 	monitoringMutex.Lock()
 	wasEnabled := monitoringEnabled
 	monitoringEnabled = enabled
+	if enabled != wasEnabled {
+		publishMonitoringStatus(enabled)
+	}
 	monitoringMutex.Unlock()
 
 	if enabled && !wasEnabled {
@@ -446,16 +616,45 @@ func SetMonitoringEnabled(enabled bool) {
 		log.Println("Мониторинг включен: начинается сбор метрик")
 
 		cacheCtx, cacheCancel = context.WithCancel(context.Background())
-		go updateCacheLoop(cacheCtx)
+		cacheDone = make(chan struct{})
+		go updateCacheLoop(cacheCtx, cacheDone)
 	} else if !enabled && wasEnabled {
 
 		log.Println("Мониторинг выключен: сбор метрик остановлен")
+		// Цикл дожидается завершения: иначе снимок процессов, обрабатываемый в момент выключения,
+		// откроет эпизоды всплесков уже после Flush, и они не будут записаны.
 		if cacheCancel != nil {
 			cacheCancel()
+			<-cacheDone
+		}
+		resetConnections()
+		if spikeRecorder != nil {
+			spikeRecorder.Flush()
+		}
+		if lifecycleTracker != nil {
+			lifecycleTracker.Reset()
 		}
 	}
 }
 
+// SetSpikeRecorder подключает детектор всплесков к циклу обновления процессов.
+// Вызывается один раз при старте сервера, до включения мониторинга.
+//
+// Параметры:
+//   - recorder: детектор всплесков или nil, чтобы отключить запись
+func SetSpikeRecorder(recorder *services.SpikeRecorder) {
+	spikeRecorder = recorder
+}
+
+// SetHistoryRecorder подключает сохранение замеров CPU и памяти в историю.
+// Вызывается один раз при старте сервера, до включения мониторинга.
+//
+// Параметры:
+//   - recorder: хранилище истории или nil, чтобы отключить запись
+func SetHistoryRecorder(recorder *services.HistoryRecorder) {
+	historyRecorder = recorder
+}
+
 // GetMonitoringEnabled возвращает текущее состояние мониторинга.
 //
 // Возвращает:
@@ -60,18 +60,34 @@ func CompletionMenu() (int, int32, string) {
 	return action, pid, name
 }
 
-func СheckSuspiciousActivityMenu() int {
+func СheckSuspiciousActivityMenu() (int, int32) {
 	var action int
+	var pid int32
 	menu := []MenuItem{
+		{1, "Повторить проверку"},
+		{2, "Показать только серьёзные находки (high и critical)"},
+		{3, "Разрешить исполняемый файл процесса по PID"},
+		{4, "Запретить исполняемый файл процесса по PID"},
 		{0, "Вернуться в главное меню"},
 	}
 
+	fmt.Println("\nМеню проверки процессов:")
 	for _, item := range menu {
 		fmt.Printf(" [%d] %s\n", item.ID, item.Text)
 	}
 
 	action = getUserInput()
-	return action
+
+	switch action {
+	case 3:
+		fmt.Print("\nВведите PID процесса, исполняемый файл которого нужно разрешить: ")
+		pid = readPID()
+	case 4:
+		fmt.Print("\nВведите PID процесса, исполняемый файл которого нужно запретить: ")
+		pid = readPID()
+	}
+
+	return action, pid
 }
 
 func getUserInput() int {
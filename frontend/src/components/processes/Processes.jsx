@@ -64,6 +64,8 @@ const Processes = () => {
 	const tableScrollRef = useRef(null)
 	const monitoringEnabledRef = useRef(monitoringEnabled)
 	const isInitializedRef = useRef(false)
+	const processesByPidRef = useRef(new Map())
+	const processesSeqRef = useRef(0)
 	const searchPidContainerRef = useRef(null)
 	const searchNameContainerRef = useRef(null)
 	const searchPortContainerRef = useRef(null)
@@ -132,7 +134,37 @@ const Processes = () => {
 		dispatch(setInitialLoading(true))
 		dispatch(setStepProgress({ step: 'websocket', progress: 10 }))
 
-		const socket = new RealtimeSocket('ws://localhost:8080/ws/processes')
+		const socket = new RealtimeSocket(
+			'ws://localhost:8080/ws/processes?encoding=delta'
+		)
+
+		// Применяет сообщение потока разниц к процессам по PID. Возвращает false,
+		// если пропущено обновление: тогда запрашивается полный список.
+		const applyProcessUpdate = msg => {
+			const processes = processesByPidRef.current
+
+			if (msg.type === 'snapshot') {
+				processes.clear()
+				;(msg.processes || []).forEach(p => processes.set(p.pid, p))
+				processesSeqRef.current = msg.seq
+				return true
+			}
+
+			if (msg.seq !== processesSeqRef.current + 1) {
+				socket.send(JSON.stringify({ type: 'resync' }))
+				return false
+			}
+
+			;(msg.removed || []).forEach(pid => processes.delete(pid))
+			;(msg.added || []).forEach(p => processes.set(p.pid, p))
+			;(msg.changed || []).forEach(({ pid, fields }) => {
+				const current = processes.get(pid)
+				if (current) processes.set(pid, { ...current, ...fields })
+			})
+			processesSeqRef.current = msg.seq
+			return true
+		}
+
 		socket.setHandlers({
 			onStatusChange: status => dispatch(setWsStatus(status)),
 			onOpen: () => {
@@ -143,29 +175,32 @@ const Processes = () => {
 				try {
 					const msg = JSON.parse(evt.data || '[]')
 
-					if (msg && typeof msg === 'object' && !Array.isArray(msg)) {
-						if (msg.monitoringEnabled !== undefined) {
-							dispatch(setMonitoringEnabled(msg.monitoringEnabled))
+					if (!msg || typeof msg !== 'object' || Array.isArray(msg)) return
 
-							if (!msg.monitoringEnabled) {
-								dispatch(clearProcesses())
-								return
-							}
-						}
+					if (msg.monitoringEnabled !== undefined) {
+						dispatch(setMonitoringEnabled(msg.monitoringEnabled))
 
+						if (!msg.monitoringEnabled) {
+							processesByPidRef.current.clear()
+							processesSeqRef.current = 0
+							dispatch(clearProcesses())
+						}
 						return
 					}
 
-					if (!Array.isArray(msg)) return
+					if (msg.type !== 'snapshot' && msg.type !== 'delta') return
 
 					if (!monitoringEnabledRef.current) {
 						dispatch(clearProcesses())
 						return
 					}
 
+					if (!applyProcessUpdate(msg)) return
+
+					const processes = [...processesByPidRef.current.values()]
 					dispatch(setStepProgress({ step: 'api', progress: 100 }))
 					setTimeout(() => {
-						dispatch(setProcesses(msg))
+						dispatch(setProcesses(processes))
 						dispatch(completeLoading())
 						dispatch(setInitialLoading(false))
 					}, 300)